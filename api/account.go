@@ -6,10 +6,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
 )
 
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,currency"`
 }
 
@@ -17,17 +17,51 @@ func (server *Server) createAccount(c *gin.Context) {
 	var acc createAccountRequest
 
 	if err := c.ShouldBindJSON(&acc); err != nil {
-		c.JSON(http.StatusBadRequest, responseError(err))
+		writeError(c, bindingError(err))
 		return
 	}
 
-	accs, err := server.db.CreateAccount(c, db.CreateAccountParams{Owner: acc.Owner, Balance: 0, Currency: acc.Currency})
+	currency, err := server.checkCurrency(c, "currency", acc.Currency)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, responseError(err))
+		writeError(c, err)
+		return
+	}
+	if !currency.Enabled {
+		writeError(c, errCurrencyDisabled)
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.db.GetUserByUsername(c, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(c, errInvalidToken)
+			return
+		}
+		writeError(c, err)
+		return
+	}
+
+	arg := db.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: acc.Currency,
+		UserID:   user.ID,
+	}
+	accs, err := server.db.CreateAccount(c, arg)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, accs)
+	p, err := server.presenter(c)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p.account(accs))
 }
 
 type getAccountRequest struct {
@@ -38,7 +72,7 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, responseError(err))
+		writeError(ctx, bindingError(err))
 		return
 	}
 
@@ -46,14 +80,26 @@ func (server *Server) getAccount(ctx *gin.Context) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, responseError(err))
+			writeError(ctx, db.ErrAccountNotFound)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, responseError(err))
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, acc)
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if acc.Owner != authPayload.Username {
+		writeError(ctx, errAccountNotOwned)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p.account(acc))
 }
 
 type listAccountsRequest struct {
@@ -65,19 +111,80 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 	var req listAccountsRequest
 
 	if err := ctx.ShouldBind(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, responseError(err))
+		writeError(ctx, bindingError(err))
 		return
 	}
 
-	arg := db.ListAccountsParams{
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListAccountsByOwnerParams{
+		Owner:  authPayload.Username,
 		Offset: (req.Page - 1) * req.Size,
 		Limit:  req.Size,
 	}
-	accounts, err := server.db.ListAccounts(ctx, arg)
+	accounts, err := server.db.ListAccountsByOwner(ctx, arg)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p.accounts(accounts))
+}
+
+type closeAccountRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// closeAccountReason is the status reason of the accounts closed by their owner
+const closeAccountReason = "closed by the owner"
+
+// closeAccount close an account of the authenticated user, it must have no balance nor
+// pending holds and can't be closed while frozen
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var req closeAccountRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	acc, err := server.db.GetAccount(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(ctx, db.ErrAccountNotFound)
+			return
+		}
+		writeError(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if acc.Owner != authPayload.Username {
+		writeError(ctx, errAccountNotOwned)
+		return
+	}
+
+	acc, err = server.db.ChangeAccountStatusTx(ctx, db.ChangeAccountStatusParams{
+		AccountID:     acc.ID,
+		Status:        db.AccountStatusClosed,
+		Reason:        closeAccountReason,
+		RequireActive: true,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, responseError(err))
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	ctx.JSON(http.StatusOK, p.account(acc))
 }
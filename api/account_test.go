@@ -15,8 +15,10 @@ import (
 	"github.com/golang/mock/gomock"
 	mockdb "github.com/hamdysherif/simplebank/db/mock"
 	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
 	"github.com/hamdysherif/simplebank/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetAccountAPI(t *testing.T) {
@@ -25,12 +27,16 @@ func TestGetAccountAPI(t *testing.T) {
 	testCases := []struct {
 		name          string
 		accountID     int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:      "OK",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -43,9 +49,62 @@ func TestGetAccountAPI(t *testing.T) {
 				requireBodyMatchResponse(t, recorder.Body, account)
 			},
 		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "unauthorized_user", util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "RefreshToken",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken(account.Owner, util.RoleCustomer, token.RefreshToken, time.Hour)
+				require.NoError(t, err)
+				request.Header.Set("authorization", "bearer "+refreshToken)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name:      "NotFound",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -60,6 +119,9 @@ func TestGetAccountAPI(t *testing.T) {
 		{
 			name:      "Badrequest",
 			accountID: int64(0),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -73,6 +135,9 @@ func TestGetAccountAPI(t *testing.T) {
 		{
 			name:      "InternalServerError",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -101,11 +166,7 @@ func TestGetAccountAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			assert.NoError(t, err)
 
-			authorizationType := "bearer"
-			token, err := server.tokenMaker.CreateToken("hamdy", time.Hour)
-			assert.NoError(t, err)
-
-			request.Header.Set("authorization", fmt.Sprintf("%s %s", authorizationType, token))
+			tc.setupAuth(t, request, server.tokenMaker)
 
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
@@ -115,24 +176,37 @@ func TestGetAccountAPI(t *testing.T) {
 }
 
 func TestCreateAccountAPI(t *testing.T) {
+	user := randomUser("secret")
 	account := randomAccount()
+	account.Owner = user.Username
+	account.UserID = user.ID
 
 	testCases := []struct {
 		name          string
 		params        gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:   "OK",
-			params: gin.H{"owner": account.Owner, "currency": account.Currency},
+			params: gin.H{"currency": account.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, user.Username, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
 				store.
 					EXPECT().
 					CreateAccount(gomock.Any(), db.CreateAccountParams{
-						Owner:    account.Owner,
+						Owner:    user.Username,
 						Currency: account.Currency,
-						Balance:  0}).
+						Balance:  0,
+						UserID:   user.ID}).
 					Times(1).
 					Return(account, nil)
 			},
@@ -142,8 +216,10 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 		},
 		{
-			name:   "BadRequestCurrencyNotProvided",
-			params: gin.H{"owner": "Ali", "currency": ""},
+			name:   "NoAuthorization",
+			params: gin.H{"currency": account.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -151,12 +227,15 @@ func TestCreateAccountAPI(t *testing.T) {
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
-			name:   "BadRequestCurrencyNotAllowed",
-			params: gin.H{"owner": "Ali", "currency": "ANY"},
+			name:   "BadRequestCurrencyNotProvided",
+			params: gin.H{"currency": ""},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, user.Username, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -168,8 +247,11 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 		},
 		{
-			name:   "BadRequestOwner",
-			params: gin.H{"owner": "", "currency": "LE"},
+			name:   "BadRequestCurrencyNotAllowed",
+			params: gin.H{"currency": "ANY"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, user.Username, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -182,11 +264,19 @@ func TestCreateAccountAPI(t *testing.T) {
 		},
 		{
 			name:   "InternalServerError",
-			params: gin.H{"owner": "Ali", "currency": "LE"},
+			params: gin.H{"currency": util.EGP},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, user.Username, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
-					CreateAccount(gomock.Any(), db.CreateAccountParams{Owner: "Ali", Currency: "LE", Balance: 0}).
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.
+					EXPECT().
+					CreateAccount(gomock.Any(), db.CreateAccountParams{Owner: user.Username, Currency: util.EGP, Balance: 0, UserID: user.ID}).
 					Times(1).
 					Return(db.Account{}, sql.ErrConnDone)
 			},
@@ -214,25 +304,236 @@ func TestCreateAccountAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 			assert.NoError(t, err)
 
-			authorizationType := "bearer"
-			token, err := server.tokenMaker.CreateToken("hamdy", time.Hour)
+			tc.setupAuth(t, request, server.tokenMaker)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+
+}
+
+func TestListAccountsAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	accounts := make([]db.Account, 5)
+	for i := range accounts {
+		accounts[i] = randomAccount()
+		accounts[i].Owner = owner
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page=1&size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ListAccountsByOwner(gomock.Any(), db.ListAccountsByOwnerParams{Owner: owner, Offset: 0, Limit: 5}).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []accountResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.NoError(t, err)
+				assert.Equal(t, testPresenter().accounts(accounts), got)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: "page=1&size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ListAccountsByOwner(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "BadRequestPageSize",
+			query: "page=1&size=100",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ListAccountsByOwner(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalServerError",
+			query: "page=1&size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ListAccountsByOwner(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := NewTestServer(t, store)
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/accounts?%s", tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
 			assert.NoError(t, err)
 
-			request.Header.Set("authorization", fmt.Sprintf("%s %s", authorizationType, token))
+			tc.setupAuth(t, request, server.tokenMaker)
 
 			recorder := httptest.NewRecorder()
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
 	}
+}
 
+func TestCloseAccountAPI(t *testing.T) {
+	account := randomAccount()
+	account.Balance = 0
+	closed := account
+	closed.Status = db.AccountStatusClosed
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(db.ChangeAccountStatusParams{
+						AccountID:     account.ID,
+						Status:        db.AccountStatusClosed,
+						Reason:        closeAccountReason,
+						RequireActive: true,
+					})).
+					Times(1).
+					Return(closed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp accountResponse
+				assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				assert.Equal(t, db.AccountStatusClosed, rsp.Status)
+			},
+		},
+		{
+			name:     "NotEmpty",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, db.ErrAccountNotEmpty)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, codeAccountNotEmpty)
+			},
+		},
+		{
+			name:     "Frozen",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, fmt.Errorf("%w: account [%v]", db.ErrAccountFrozen, account.ID))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, codeAccountFrozen)
+			},
+		},
+		{
+			name:     "NotOwned",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			url := fmt.Sprintf("/accounts/%d/close", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			assert.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, tc.username, util.RoleCustomer)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
 }
+
 func randomAccount() db.Account {
 	return db.Account{
 		ID:       util.RandomInt(1, 10),
 		Owner:    util.RandomOwner(),
 		Currency: util.RandomCurrency(),
 		Balance:  util.RandomeBalance(),
+		Status:   db.AccountStatusActive,
+		// customer accounts start without an overdraft
+		OverdraftLimit: sql.NullInt64{Valid: true},
 	}
 }
 
@@ -240,8 +541,8 @@ func requireBodyMatchResponse(t *testing.T, body *bytes.Buffer, account db.Accou
 	b, err := ioutil.ReadAll(body)
 	assert.NoError(t, err)
 
-	var getAccount db.Account
+	var getAccount accountResponse
 	err = json.Unmarshal(b, &getAccount)
 	assert.NoError(t, err)
-	assert.Equal(t, getAccount, account)
+	assert.Equal(t, testPresenter().account(account), getAccount)
 }
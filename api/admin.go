@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+)
+
+type revokeUserSessionsRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// revokeUserSessions block every active session of a user and revoke their refresh tokens
+// along with every access token already issued to the user
+func (server *Server) revokeUserSessions(ctx *gin.Context) {
+	var req revokeUserSessionsRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	revoked, err := server.revokeSessions(ctx, req.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"revoked_sessions": revoked})
+}
+
+// revokeSessions block every active session of username, revoking their refresh tokens
+// and the user's access tokens in this server right away, and return how many were revoked
+func (server *Server) revokeSessions(ctx context.Context, username string) (int, error) {
+	result, err := server.db.RevokeUserSessionsTx(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, session := range result.Sessions {
+		server.revocations.markRevoked(session.ID, session.ExpiresAt)
+	}
+	server.revocations.setTokensRevokedAt(username, result.RevokedAt)
+	return len(result.Sessions), nil
+}
+
+type listAllAccountsRequest struct {
+	Page int32 `form:"page" binding:"required,min=1"`
+	Size int32 `form:"size" binding:"required,min=5,max=20"`
+}
+
+// listAllAccounts list the accounts of every user for back office staff
+func (server *Server) listAllAccounts(ctx *gin.Context) {
+	var req listAllAccountsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	arg := db.ListAccountsParams{
+		Offset: (req.Page - 1) * req.Size,
+		Limit:  req.Size,
+	}
+	accounts, err := server.db.ListAccounts(ctx, arg)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p.accounts(accounts))
+}
+
+type updateUserRoleURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+// updateUserRole change the role of a user and revoke their sessions and the access
+// tokens already issued to them, so the new role takes effect when they log in again
+// rather than when those tokens expire
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uri updateUserRoleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	var req updateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	user, err := server.db.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: uri.Username,
+		Role:     req.Role,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if _, err := server.revokeSessions(ctx, user.Username); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"username": user.Username, "role": user.Role})
+}
+
+type updateAccountStatusURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type updateAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen closed"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// updateAccountStatus freeze, unfreeze or close an account, closing is final and
+// needs an account without balance nor pending holds
+func (server *Server) updateAccountStatus(ctx *gin.Context) {
+	var uri updateAccountStatusURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	var req updateAccountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	account, err := server.db.ChangeAccountStatusTx(ctx, db.ChangeAccountStatusParams{
+		AccountID: uri.ID,
+		Status:    req.Status,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p.account(account))
+}
+
+type updateOverdraftLimitURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type updateOverdraftLimitRequest struct {
+	// Limit is in the currency of the account, "0" removes the overdraft
+	Limit string `json:"limit" binding:"required"`
+}
+
+// updateOverdraftLimit change how far below zero the balance of a customer account
+// can go, the limit can't be lowered past what is already overdrawn
+func (server *Server) updateOverdraftLimit(ctx *gin.Context) {
+	var uri updateOverdraftLimitURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	var req updateOverdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	account, err := server.db.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(ctx, db.ErrAccountNotFound)
+			return
+		}
+		writeError(ctx, err)
+		return
+	}
+
+	limit, err := server.parseMoney(ctx, "limit", req.Limit, account.Currency)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if limit.MinorUnits < 0 {
+		writeError(ctx, invalidField("limit", "must not be negative"))
+		return
+	}
+
+	account, err = server.db.SetOverdraftLimitTx(ctx, db.SetOverdraftLimitParams{
+		AccountID: account.ID,
+		Limit:     limit.MinorUnits,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p.account(account))
+}
+
+// bootstrapAdmin makes BOOTSTRAP_ADMIN an admin while the bank has none, so the first
+// admin doesn't need access to the database. Nothing changes once an admin exists or
+// when no user has the name
+func (server *Server) bootstrapAdmin(ctx context.Context) error {
+	user, err := server.db.PromoteFirstAdmin(ctx, server.config.BootstrapAdmin)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	log.Printf("user %s is the first admin", user.Username)
+	return nil
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+)
+
+// outcomes of a transfer of a best effort batch
+const (
+	batchItemSucceeded = "succeeded"
+	batchItemFailed    = "failed"
+)
+
+type batchTransferItemRequest struct {
+	ToAccount int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount    string `json:"amount" binding:"required"`
+}
+
+type batchTransferRequest struct {
+	FromAccount int64  `json:"from_account_id" binding:"required,min=1"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	Atomic      bool   `json:"atomic"`
+	// at most 100 items keep an atomic batch a short transaction
+	Items []batchTransferItemRequest `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+type batchTransferItemResponse struct {
+	Status string                  `json:"status"`
+	Result *transferResultResponse `json:"result,omitempty"`
+	Error  *apiError               `json:"error,omitempty"`
+}
+
+type batchTransferResponse struct {
+	Atomic    bool                        `json:"atomic"`
+	Total     util.Money                  `json:"total"`
+	Succeeded int                         `json:"succeeded"`
+	Failed    int                         `json:"failed"`
+	Items     []batchTransferItemResponse `json:"items"`
+}
+
+// batchTransfer make many transfers from one account of the authenticated user. The
+// currency, the destinations and the total are checked before any transfer, then an
+// atomic batch makes every transfer or none while a best effort batch reports the
+// outcome of each
+func (server *Server) batchTransfer(ctx *gin.Context) {
+	var req batchTransferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	if _, err := server.checkCurrency(ctx, "currency", req.Currency); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	items := make([]db.BatchTransferItem, len(req.Items))
+	var total int64
+	for i, item := range req.Items {
+		amount, err := server.parseAmount(ctx, fmt.Sprintf("items[%d].amount", i), item.Amount, req.Currency)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		if total > math.MaxInt64-amount.MinorUnits {
+			writeError(ctx, invalidField("items", "total amount is out of range"))
+			return
+		}
+
+		total += amount.MinorUnits
+		items[i] = db.BatchTransferItem{ToAccountID: item.ToAccount, Amount: amount.MinorUnits}
+	}
+
+	fromAccount, valid := isValidAccount(server, ctx, req.FromAccount, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, errAccountNotOwned)
+		return
+	}
+	if headroom(fromAccount) < total {
+		writeError(ctx, db.ErrInsufficientFunds)
+		return
+	}
+
+	// destinations may be in other currencies, the store converts their amounts
+	checked := make(map[int64]bool)
+	for _, item := range items {
+		if checked[item.ToAccountID] {
+			continue
+		}
+		if _, valid := getRequestAccount(server, ctx, item.ToAccountID); !valid {
+			return
+		}
+		checked[item.ToAccountID] = true
+	}
+
+	result, err := server.db.BatchTransferTx(ctx, db.BatchTransferParams{
+		FromAccountID: req.FromAccount,
+		Items:         items,
+		Atomic:        req.Atomic,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	rsp := batchTransferResponse{
+		Atomic: req.Atomic,
+		Total:  p.money(total, fromAccount.Currency),
+		Items:  make([]batchTransferItemResponse, len(result.Items)),
+	}
+	for i, item := range result.Items {
+		if item.Err != nil {
+			apiErr := toAPIError(item.Err)
+			if apiErr.status == http.StatusInternalServerError {
+				_ = ctx.Error(item.Err)
+			}
+			rsp.Items[i] = batchTransferItemResponse{Status: batchItemFailed, Error: apiErr}
+			rsp.Failed++
+			continue
+		}
+
+		transferred := p.transferResult(item.Result, authPayload)
+		rsp.Items[i] = batchTransferItemResponse{Status: batchItemSucceeded, Result: &transferred}
+		rsp.Succeeded++
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
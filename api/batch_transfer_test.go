@@ -0,0 +1,225 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferAPI(t *testing.T) {
+	from := randomAccount()
+	from.Currency = util.USD
+	from.Balance = 1000
+	to1 := db.Account{ID: from.ID + 10, Owner: util.RandomOwner(), Currency: util.USD}
+	to2 := db.Account{ID: from.ID + 20, Owner: util.RandomOwner(), Currency: util.USD}
+
+	body := func(atomic bool, amounts ...string) gin.H {
+		items := []gin.H{}
+		for i, amount := range amounts {
+			to := to1
+			if i%2 == 1 {
+				to = to2
+			}
+			items = append(items, gin.H{"to_account_id": to.ID, "amount": amount})
+		}
+		return gin.H{
+			"from_account_id": from.ID,
+			"currency":        from.Currency,
+			"atomic":          atomic,
+			"items":           items,
+		}
+	}
+	params := func(atomic bool) db.BatchTransferParams {
+		return db.BatchTransferParams{
+			FromAccountID: from.ID,
+			Items: []db.BatchTransferItem{
+				{ToAccountID: to1.ID, Amount: 100},
+				{ToAccountID: to2.ID, Amount: 250},
+			},
+			Atomic: atomic,
+		}
+	}
+	stubAccounts := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to1.ID)).Times(1).Return(to1, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to2.ID)).Times(1).Return(to2, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Atomic",
+			body:     body(true, "1", "2.50"),
+			username: from.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(params(true))).
+					Times(1).
+					Return(db.BatchTransferResult{Items: []db.BatchTransferItemResult{{}, {}}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp batchTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, rsp.Atomic)
+				require.Equal(t, int64(350), rsp.Total.MinorUnits)
+				require.Equal(t, 2, rsp.Succeeded)
+				require.Zero(t, rsp.Failed)
+			},
+		},
+		{
+			name:     "AtomicRolledBack",
+			body:     body(true, "1", "2.50"),
+			username: from.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(params(true))).
+					Times(1).
+					Return(db.BatchTransferResult{}, &db.BatchItemError{Index: 1, Err: db.ErrNoExchangeRate})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeNoExchangeRate)
+
+				var body struct {
+					Error apiError `json:"error"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Len(t, body.Error.Details, 1)
+				require.Equal(t, "items[1]", body.Error.Details[0].Field)
+			},
+		},
+		{
+			name:     "BestEffort",
+			body:     body(false, "1", "2.50"),
+			username: from.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(params(false))).
+					Times(1).
+					Return(db.BatchTransferResult{Items: []db.BatchTransferItemResult{
+						{},
+						{Err: db.ErrInsufficientFunds},
+					}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp batchTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.False(t, rsp.Atomic)
+				require.Equal(t, 1, rsp.Succeeded)
+				require.Equal(t, 1, rsp.Failed)
+				require.Equal(t, batchItemSucceeded, rsp.Items[0].Status)
+				require.NotNil(t, rsp.Items[0].Result)
+				require.Equal(t, batchItemFailed, rsp.Items[1].Status)
+				require.Equal(t, codeInsufficientFunds, rsp.Items[1].Error.Code)
+			},
+		},
+		{
+			name:     "TotalExceedsBalance",
+			body:     body(false, "6", "5"),
+			username: from.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeInsufficientFunds)
+			},
+		},
+		{
+			name:     "TooPrecise",
+			body:     body(true, "1", "2.505"),
+			username: from.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeInvalidRequest)
+			},
+		},
+		{
+			name:     "NoItems",
+			body:     body(true),
+			username: from.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeInvalidRequest)
+			},
+		},
+		{
+			name:     "DestinationNotFound",
+			body:     body(true, "1", "2.50"),
+			username: from.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to1.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeAccountNotFound)
+			},
+		},
+		{
+			name:     "AccountNotOwned",
+			body:     body(true, "1", "2.50"),
+			username: "unauthorized",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, codeForbidden)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			addAuthorization(t, request, server.tokenMaker, tc.username, util.RoleCustomer)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+)
+
+type cashRequest struct {
+	Amount   string `json:"amount" binding:"required"`
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+type cashResponse struct {
+	Account  accountResponse  `json:"account"`
+	Transfer transferResponse `json:"transfer"`
+	Entry    entryResponse    `json:"entry"`
+}
+
+// depositMoney credit an account against the cash account of its currency
+func (server *Server) depositMoney(ctx *gin.Context) {
+	server.moveCash(ctx, server.db.DepositTx)
+}
+
+// withdrawMoney debit an account to the cash account of its currency
+func (server *Server) withdrawMoney(ctx *gin.Context) {
+	server.moveCash(ctx, server.db.WithdrawTx)
+}
+
+func (server *Server) moveCash(ctx *gin.Context, tx func(ctx context.Context, arg db.CashParams) (db.CashResult, error)) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	var req cashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	if _, err := server.checkCurrency(ctx, "currency", req.Currency); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	amount, err := server.parseAmount(ctx, "amount", req.Amount, req.Currency)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	result, err := tx(ctx, db.CashParams{
+		AccountID: uri.ID,
+		Amount:    amount.MinorUnits,
+		Currency:  amount.Currency,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	// both sides of a cash transfer are in the currency of the account
+	ctx.JSON(http.StatusOK, cashResponse{
+		Account:  p.account(result.Account),
+		Transfer: p.transfer(result.Transfer, result.Account.Currency, result.Account.Currency),
+		Entry:    p.entry(result.Entry, result.Account.Currency),
+	})
+}
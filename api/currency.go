@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+)
+
+// currencyCacheTTL is how long the currencies table is trusted before it is read again
+const currencyCacheTTL = time.Minute
+
+// currencyCache keep the currencies table in memory for request validation, a failed
+// refresh keeps serving the last list it read
+type currencyCache struct {
+	store db.Store
+	ttl   time.Duration
+
+	mu         sync.RWMutex
+	currencies []db.Currency
+	byCode     map[string]db.Currency
+	loadedAt   time.Time
+}
+
+func newCurrencyCache(store db.Store, ttl time.Duration) *currencyCache {
+	return &currencyCache{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// list return every currency ordered by code
+func (cache *currencyCache) list(ctx context.Context) ([]db.Currency, error) {
+	cache.mu.RLock()
+	currencies, loadedAt := cache.currencies, cache.loadedAt
+	cache.mu.RUnlock()
+
+	if currencies != nil && time.Since(loadedAt) < cache.ttl {
+		return currencies, nil
+	}
+
+	loaded, err := cache.store.ListCurrencies(ctx)
+	if err != nil {
+		if currencies != nil {
+			log.Printf("can't refresh currencies, using the list from %v: %v", loadedAt, err)
+			return currencies, nil
+		}
+		return nil, err
+	}
+
+	byCode := make(map[string]db.Currency, len(loaded))
+	for _, currency := range loaded {
+		byCode[currency.Code] = currency
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.currencies = loaded
+	cache.byCode = byCode
+	cache.loadedAt = time.Now()
+	return loaded, nil
+}
+
+// get return the currency with code and whether it exists
+func (cache *currencyCache) get(ctx context.Context, code string) (db.Currency, bool, error) {
+	if _, err := cache.list(ctx); err != nil {
+		return db.Currency{}, false, err
+	}
+
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	currency, ok := cache.byCode[code]
+	return currency, ok, nil
+}
+
+// listCurrencies return the supported currencies, disabled ones included
+func (server *Server) listCurrencies(ctx *gin.Context) {
+	currencies, err := server.currencies.list(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, currencies)
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrenciesAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := NewTestServer(t, store)
+
+	request := httptest.NewRequest(http.MethodGet, "/currencies", nil)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var currencies []db.Currency
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &currencies))
+	require.Len(t, currencies, 4)
+	require.Equal(t, util.EGP, currencies[0].Code)
+	require.Equal(t, int32(2), currencies[0].Exponent)
+}
+
+func TestCreateAccountDisabledCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.
+		EXPECT().
+		ListCurrencies(gomock.Any()).
+		Times(1).
+		Return([]db.Currency{{Code: util.EGP, Exponent: 2, Enabled: false}}, nil)
+	store.
+		EXPECT().
+		CreateAccount(gomock.Any(), gomock.Any()).
+		Times(0)
+	server := NewTestServer(t, store)
+
+	data, err := json.Marshal(gin.H{"currency": util.EGP})
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+	addAuthorization(t, request, server.tokenMaker, util.RandomOwner(), util.RoleCustomer)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	requireErrorCode(t, recorder, codeCurrencyDisabled)
+}
+
+func TestCreateAccountUnsupportedCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.
+		EXPECT().
+		CreateAccount(gomock.Any(), gomock.Any()).
+		Times(0)
+	server := NewTestServer(t, store)
+
+	data, err := json.Marshal(gin.H{"currency": "XYZ"})
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+	addAuthorization(t, request, server.tokenMaker, util.RandomOwner(), util.RoleCustomer)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	requireErrorCode(t, recorder, codeInvalidRequest)
+}
+
+func TestCreateAccountCurrencyLookupError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// the currencies can't be read and there is no list to fall back to
+	store.
+		EXPECT().
+		ListCurrencies(gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	store.
+		EXPECT().
+		CreateAccount(gomock.Any(), gomock.Any()).
+		Times(0)
+	server := NewTestServer(t, store)
+
+	data, err := json.Marshal(gin.H{"currency": util.EGP})
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+	addAuthorization(t, request, server.tokenMaker, util.RandomOwner(), util.RoleCustomer)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestCurrencyCacheKeepsListOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.
+			EXPECT().
+			ListCurrencies(gomock.Any()).
+			Times(1).
+			Return(nil, sql.ErrConnDone),
+		store.
+			EXPECT().
+			ListCurrencies(gomock.Any()).
+			Times(1).
+			Return([]db.Currency{{Code: util.USD, Exponent: 2, Enabled: true}}, nil),
+		store.
+			EXPECT().
+			ListCurrencies(gomock.Any()).
+			Times(1).
+			Return(nil, sql.ErrConnDone),
+	)
+
+	// a zero ttl reads the table on every lookup
+	cache := newCurrencyCache(store, 0)
+
+	// nothing to fall back to yet
+	_, _, err := cache.get(context.Background(), util.USD)
+	require.Error(t, err)
+
+	currency, ok, err := cache.get(context.Background(), util.USD)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, currency.Enabled)
+
+	_, ok, err = cache.get(context.Background(), util.USD)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
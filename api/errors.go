@@ -0,0 +1,274 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+)
+
+// stable error codes clients can branch on, messages may change
+const (
+	codeInvalidRequest      = "invalid_request"
+	codeInvalidToken        = "invalid_token"
+	codeTokenExpired        = "token_expired"
+	codeTokenRevoked        = "token_revoked"
+	codeInvalidSession      = "invalid_session"
+	codeInvalidCredentials  = "invalid_credentials"
+	codeForbidden           = "forbidden"
+	codeNotFound            = "not_found"
+	codeAccountNotFound     = "account_not_found"
+	codeAccountFrozen       = "account_frozen"
+	codeAccountClosed       = "account_closed"
+	codeAccountNotEmpty     = "account_not_empty"
+	codeOverdraftInUse      = "overdraft_in_use"
+	codeSystemAccount       = "system_account"
+	codeTransferLimit       = "transfer_limit_exceeded"
+	codeCurrencyMismatch    = "currency_mismatch"
+	codeCurrencyDisabled    = "currency_disabled"
+	codeInsufficientFunds   = "insufficient_funds"
+	codeAlreadyReversed     = "already_reversed"
+	codeReversalReversed    = "reversal_not_reversible"
+	codeScheduleClosed      = "schedule_closed"
+	codeScheduleChanged     = "schedule_changed"
+	codeHoldNotPending      = "hold_not_pending"
+	codeHoldExpired         = "hold_expired"
+	codeCaptureExceedsHold  = "capture_exceeds_hold"
+	codeNoExchangeRate      = "no_exchange_rate"
+	codeAmountTooSmall      = "amount_too_small"
+	codeAlreadyExists       = "already_exists"
+	codeInvalidReference    = "invalid_reference"
+	codeIdempotencyKeyReuse = "idempotency_key_reused"
+	codeTxConflict          = "transaction_conflict"
+	codeInternal            = "internal_error"
+)
+
+// fieldError describe why a single request field failed validation
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// apiError is the body of every error response, wrapped as {"error": apiError}
+type apiError struct {
+	status  int
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details []fieldError `json:"details,omitempty"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func newAPIError(status int, code string, message string) *apiError {
+	return &apiError{status: status, Code: code, Message: message}
+}
+
+var (
+	errAccountNotOwned      = newAPIError(http.StatusForbidden, codeForbidden, "account doesn't belong to the authenticated user")
+	errInsufficientRole     = newAPIError(http.StatusForbidden, codeForbidden, "insufficient role for this operation")
+	errInvalidToken         = newAPIError(http.StatusUnauthorized, codeInvalidToken, "invalid token")
+	errTokenRevoked         = newAPIError(http.StatusUnauthorized, codeTokenRevoked, "token has been revoked")
+	errInvalidCredentials   = newAPIError(http.StatusForbidden, codeInvalidCredentials, "invalid username or password")
+	errCurrencyDisabled     = newAPIError(http.StatusUnprocessableEntity, codeCurrencyDisabled, "accounts can't be opened in a disabled currency")
+	errIdempotencyKeyReused = newAPIError(http.StatusUnprocessableEntity, codeIdempotencyKeyReuse, "idempotency key was already used with a different request")
+)
+
+// txConflictRetryAfter is the Retry-After, in seconds, of a request rolled back by
+// contention on the rows it writes
+const txConflictRetryAfter = "1"
+
+// writeError respond with the envelope of err, errors that don't map to a known
+// code are reported as internal errors without leaking their message
+func writeError(ctx *gin.Context, err error) {
+	apiErr := toAPIError(err)
+	if apiErr.status == http.StatusInternalServerError {
+		// keep the cause in the gin context for logging
+		_ = ctx.Error(err)
+	}
+	if apiErr.Code == codeTxConflict {
+		ctx.Header("Retry-After", txConflictRetryAfter)
+	}
+
+	ctx.JSON(apiErr.status, gin.H{"error": apiErr})
+}
+
+// abortWithError is writeError for middlewares, it stops the handler chain
+func abortWithError(ctx *gin.Context, err error) {
+	writeError(ctx, err)
+	ctx.Abort()
+}
+
+// bindingError report a failed ShouldBind* call as an invalid request
+func bindingError(err error) *apiError {
+	apiErr := newAPIError(http.StatusBadRequest, codeInvalidRequest, "invalid request")
+
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fieldErr := range validationErrs {
+			apiErr.Details = append(apiErr.Details, fieldError{
+				Field:   fieldErr.Field(),
+				Message: validationMessage(fieldErr),
+			})
+		}
+	case errors.As(err, &typeErr):
+		apiErr.Details = []fieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be a %s", typeErr.Type),
+		}}
+	case errors.As(err, &syntaxErr):
+		apiErr.Message = "malformed request body"
+	}
+	return apiErr
+}
+
+// toAPIError map domain and database errors to their status and code
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var itemErr *db.BatchItemError
+	if errors.As(err, &itemErr) {
+		return batchItemError(itemErr)
+	}
+
+	var constraintErr *db.ConstraintError
+	switch {
+	case errors.Is(err, db.ErrAccountNotFound):
+		return newAPIError(http.StatusNotFound, codeAccountNotFound, "account not found")
+	case errors.Is(err, db.ErrAccountFrozen):
+		return newAPIError(http.StatusConflict, codeAccountFrozen, err.Error())
+	case errors.Is(err, db.ErrAccountClosed):
+		return newAPIError(http.StatusConflict, codeAccountClosed, err.Error())
+	case errors.Is(err, db.ErrAccountNotEmpty):
+		return newAPIError(http.StatusConflict, codeAccountNotEmpty, "account can't be closed while it has a balance or pending holds")
+	case errors.Is(err, db.ErrOverdraftInUse):
+		return newAPIError(http.StatusConflict, codeOverdraftInUse, "balance is already below the new overdraft limit")
+	case errors.Is(err, db.ErrSystemAccount):
+		return newAPIError(http.StatusConflict, codeSystemAccount, "the bank's own accounts can't change status")
+	case errors.Is(err, db.ErrTransferLimitExceeded):
+		return newAPIError(http.StatusUnprocessableEntity, codeTransferLimit, err.Error())
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return newAPIError(http.StatusUnprocessableEntity, codeInsufficientFunds, "insufficient funds")
+	case errors.Is(err, db.ErrAlreadyReversed):
+		return newAPIError(http.StatusConflict, codeAlreadyReversed, "transfer has already been reversed")
+	case errors.Is(err, db.ErrReversalNotReversible):
+		return newAPIError(http.StatusConflict, codeReversalReversed, "a reversal can't be reversed, make a new transfer instead")
+	case errors.Is(err, db.ErrNoExchangeRate):
+		return newAPIError(http.StatusUnprocessableEntity, codeNoExchangeRate, err.Error())
+	case errors.Is(err, db.ErrAmountTooSmall):
+		return newAPIError(http.StatusUnprocessableEntity, codeAmountTooSmall, "amount is too small to convert to the currency of the to account")
+	case errors.Is(err, db.ErrHoldNotPending):
+		return newAPIError(http.StatusConflict, codeHoldNotPending, "hold was already captured, voided or expired")
+	case errors.Is(err, db.ErrHoldExpired):
+		return newAPIError(http.StatusConflict, codeHoldExpired, "hold has expired")
+	case errors.Is(err, db.ErrCaptureExceedsHold):
+		return newAPIError(http.StatusUnprocessableEntity, codeCaptureExceedsHold, "capture amount exceeds the held amount")
+	case errors.Is(err, db.ErrTxConflict):
+		return newAPIError(http.StatusServiceUnavailable, codeTxConflict, "request conflicted with concurrent updates, retry it")
+	case errors.Is(err, db.ErrCurrencyMismatch):
+		return newAPIError(http.StatusBadRequest, codeCurrencyMismatch, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		return newAPIError(http.StatusNotFound, codeNotFound, "resource not found")
+	case errors.Is(err, token.ErrExpiredToken):
+		return newAPIError(http.StatusUnauthorized, codeTokenExpired, "token has expired")
+	case errors.Is(err, token.ErrInvalidToken):
+		return errInvalidToken
+	case errors.As(db.ParseError(err), &constraintErr):
+		return constraintError(constraintErr)
+	}
+
+	return newAPIError(http.StatusInternalServerError, codeInternal, "internal server error")
+}
+
+// batchItemError report the failure that rolled an atomic batch back on the item
+// that caused it
+func batchItemError(err *db.BatchItemError) *apiError {
+	// copy, the cause may map to a shared error
+	apiErr := *toAPIError(err.Err)
+	apiErr.Details = []fieldError{{
+		Field:   fmt.Sprintf("items[%d]", err.Index),
+		Message: apiErr.Message,
+	}}
+	return &apiErr
+}
+
+// constraintFields name the request field behind constraints that don't follow the
+// postgres default naming
+var constraintFields = map[string]string{
+	"account_currency_unique":         "currency",
+	"exchange_rate_valid_from_unique": "valid_from",
+}
+
+// constraintError report a constraint violation with the field it is on when known
+func constraintError(err *db.ConstraintError) *apiError {
+	apiErr := newAPIError(http.StatusUnprocessableEntity, codeInvalidReference, "referenced resource does not exist")
+	detail := "does not exist"
+	if errors.Is(err, db.ErrUniqueViolation) {
+		apiErr = newAPIError(http.StatusConflict, codeAlreadyExists, "resource already exists")
+		detail = "already exists"
+	}
+
+	if field := constraintField(err); field != "" {
+		apiErr.Details = []fieldError{{Field: field, Message: detail}}
+	}
+	return apiErr
+}
+
+// constraintField return the column of a <table>_<column>_key or _fkey constraint
+func constraintField(err *db.ConstraintError) string {
+	if field, ok := constraintFields[err.Constraint]; ok {
+		return field
+	}
+
+	if !strings.HasPrefix(err.Constraint, err.Table+"_") {
+		return ""
+	}
+	field := strings.TrimPrefix(err.Constraint, err.Table+"_")
+	for _, suffix := range []string{"_fkey", "_key"} {
+		if strings.HasSuffix(field, suffix) {
+			return strings.TrimSuffix(field, suffix)
+		}
+	}
+	return ""
+}
+
+// validationMessage describe a failed binding tag in words
+func validationMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", err.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", err.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", err.Param())
+	case "email":
+		return "must be a valid email address"
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "currency":
+		return "must be a three letter currency code"
+	case "role":
+		return "is not a supported role"
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", err.Param())
+	case "exchange_rate":
+		return "must be a positive decimal number"
+	}
+	return fmt.Sprintf("failed the %s check", err.Tag())
+}
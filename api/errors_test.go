@@ -0,0 +1,132 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToAPIError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		details []fieldError
+	}{
+		{
+			name:   "InsufficientFunds",
+			err:    fmt.Errorf("transfer: %w", db.ErrInsufficientFunds),
+			status: http.StatusUnprocessableEntity,
+			code:   codeInsufficientFunds,
+		},
+		{
+			name:   "AccountNotFound",
+			err:    db.ErrAccountNotFound,
+			status: http.StatusNotFound,
+			code:   codeAccountNotFound,
+		},
+		{
+			name:   "CurrencyMismatch",
+			err:    fmt.Errorf("%w: USD vs EUR", db.ErrCurrencyMismatch),
+			status: http.StatusBadRequest,
+			code:   codeCurrencyMismatch,
+		},
+		{
+			name:   "NoRows",
+			err:    sql.ErrNoRows,
+			status: http.StatusNotFound,
+			code:   codeNotFound,
+		},
+		{
+			name:   "ExpiredToken",
+			err:    token.ErrExpiredToken,
+			status: http.StatusUnauthorized,
+			code:   codeTokenExpired,
+		},
+		{
+			name:    "DuplicateUsername",
+			err:     &pq.Error{Code: "23505", Table: "users", Constraint: "users_username_key"},
+			status:  http.StatusConflict,
+			code:    codeAlreadyExists,
+			details: []fieldError{{Field: "username", Message: "already exists"}},
+		},
+		{
+			name:    "DuplicateAccountCurrency",
+			err:     &pq.Error{Code: "23505", Table: "accounts", Constraint: "account_currency_unique"},
+			status:  http.StatusConflict,
+			code:    codeAlreadyExists,
+			details: []fieldError{{Field: "currency", Message: "already exists"}},
+		},
+		{
+			name:   "ForeignKeyViolation",
+			err:    &pq.Error{Code: "23503", Table: "sessions", Constraint: "fk_sessions_users"},
+			status: http.StatusUnprocessableEntity,
+			code:   codeInvalidReference,
+		},
+		{
+			name:   "TxConflict",
+			err:    &db.TxConflictError{Reason: "serialization_failure", Attempts: 5, Err: &pq.Error{Code: "40001"}},
+			status: http.StatusServiceUnavailable,
+			code:   codeTxConflict,
+		},
+		{
+			name:   "Internal",
+			err:    errors.New("pq: connection reset by peer"),
+			status: http.StatusInternalServerError,
+			code:   codeInternal,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := toAPIError(tc.err)
+			require.Equal(t, tc.status, apiErr.status)
+			require.Equal(t, tc.code, apiErr.Code)
+			require.Equal(t, tc.details, apiErr.Details)
+		})
+	}
+}
+
+func TestToAPIErrorHidesInternalMessage(t *testing.T) {
+	apiErr := toAPIError(errors.New(`pq: relation "accounts" does not exist`))
+	require.NotContains(t, apiErr.Message, "accounts")
+}
+
+func TestBindingErrorDetails(t *testing.T) {
+	registerCustomValidators()
+
+	req := transferRequest{FromAccount: 1, Currency: "usd"}
+	err := binding.Validator.ValidateStruct(&req)
+	require.Error(t, err)
+
+	apiErr := bindingError(err)
+	require.Equal(t, http.StatusBadRequest, apiErr.status)
+	require.Equal(t, codeInvalidRequest, apiErr.Code)
+	require.ElementsMatch(t, []fieldError{
+		{Field: "to_account_id", Message: "is required"},
+		{Field: "amount", Message: "is required"},
+		{Field: "currency", Message: "must be a three letter currency code"},
+	}, apiErr.Details)
+}
+
+// requireErrorCode check the response is an error envelope with code
+func requireErrorCode(t *testing.T, recorder *httptest.ResponseRecorder, code string) {
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, code, body.Error.Code)
+	require.NotEmpty(t, body.Error.Message)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+)
+
+type exchangeRateRequest struct {
+	BaseCurrency  string    `json:"base_currency" binding:"required,currency"`
+	QuoteCurrency string    `json:"quote_currency" binding:"required,currency,nefield=BaseCurrency"`
+	Rate          string    `json:"rate" binding:"required,exchange_rate"`
+	ValidFrom     time.Time `json:"valid_from"`
+}
+
+type createExchangeRatesRequest struct {
+	Rates []exchangeRateRequest `json:"rates" binding:"required,min=1,max=100,dive"`
+}
+
+// createExchangeRates load a batch of exchange rates, a rate without valid_from takes
+// effect immediately
+func (server *Server) createExchangeRates(ctx *gin.Context) {
+	var req createExchangeRatesRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	for i, rate := range req.Rates {
+		if _, err := server.checkCurrency(ctx, fmt.Sprintf("rates[%d].base_currency", i), rate.BaseCurrency); err != nil {
+			writeError(ctx, err)
+			return
+		}
+		if _, err := server.checkCurrency(ctx, fmt.Sprintf("rates[%d].quote_currency", i), rate.QuoteCurrency); err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+
+	now := time.Now()
+	args := make([]db.CreateExchangeRateParams, len(req.Rates))
+	for i, rate := range req.Rates {
+		validFrom := rate.ValidFrom
+		if validFrom.IsZero() {
+			validFrom = now
+		}
+
+		args[i] = db.CreateExchangeRateParams{
+			BaseCurrency:  rate.BaseCurrency,
+			QuoteCurrency: rate.QuoteCurrency,
+			Rate:          rate.Rate,
+			ValidFrom:     validFrom,
+		}
+	}
+
+	rates, err := server.db.CreateExchangeRatesTx(ctx, args)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"rates": rates})
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateExchangeRatesAPI(t *testing.T) {
+	validFrom := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	rate := gin.H{"base_currency": "USD", "quote_currency": "EUR", "rate": "0.92", "valid_from": validFrom}
+
+	testCases := []struct {
+		name          string
+		role          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.RoleAdmin,
+			body: gin.H{"rates": []gin.H{rate}},
+			buildStubs: func(store *mockdb.MockStore) {
+				args := []db.CreateExchangeRateParams{{
+					BaseCurrency:  "USD",
+					QuoteCurrency: "EUR",
+					Rate:          "0.92",
+					ValidFrom:     validFrom,
+				}}
+				store.
+					EXPECT().
+					CreateExchangeRatesTx(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return([]db.ExchangeRate{{ID: 1, BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: "0.92", ValidFrom: validFrom}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var body struct {
+					Rates []db.ExchangeRate `json:"rates"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Len(t, body.Rates, 1)
+				require.Equal(t, "0.92", body.Rates[0].Rate)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: util.RoleTeller,
+			body: gin.H{"rates": []gin.H{rate}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					CreateExchangeRatesTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRate",
+			role: util.RoleAdmin,
+			body: gin.H{"rates": []gin.H{{"base_currency": "USD", "quote_currency": "EUR", "rate": "1e3"}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					CreateExchangeRatesTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeInvalidRequest)
+			},
+		},
+		{
+			name: "SameCurrency",
+			role: util.RoleAdmin,
+			body: gin.H{"rates": []gin.H{{"base_currency": "USD", "quote_currency": "USD", "rate": "1"}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					CreateExchangeRatesTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoRates",
+			role: util.RoleAdmin,
+			body: gin.H{"rates": []gin.H{}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					CreateExchangeRatesTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateValidFrom",
+			role: util.RoleAdmin,
+			body: gin.H{"rates": []gin.H{rate}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					CreateExchangeRatesTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &db.ConstraintError{Kind: db.ErrUniqueViolation, Table: "exchange_rates", Constraint: "exchange_rate_valid_from_unique"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, codeAlreadyExists)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/admin/exchange_rates", bytes.NewReader(data))
+			addAuthorization(t, request, server.tokenMaker, util.RandomOwner(), tc.role)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+)
+
+type transferQuoteResponse struct {
+	Amount util.Money  `json:"amount"`
+	Fee    feeResponse `json:"fee"`
+	// Total is what the from account would be debited, the amount and its fee
+	Total util.Money `json:"total"`
+}
+
+// quoteTransfer return the fee POST /transfers would charge for the same request,
+// nothing is transferred
+func (server *Server) quoteTransfer(ctx *gin.Context) {
+	var req transferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	if _, err := server.checkCurrency(ctx, "currency", req.Currency); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	amount, err := server.parseAmount(ctx, "amount", req.Amount, req.Currency)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	fromAccount, valid := isValidAccount(server, ctx, req.FromAccount, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, errAccountNotOwned)
+		return
+	}
+	if _, valid := getRequestAccount(server, ctx, req.ToAccount); !valid {
+		return
+	}
+
+	fee, err := server.db.QuoteFee(ctx, db.TransferParams{
+		FromAccountID: req.FromAccount,
+		ToAccountID:   req.ToAccount,
+		Amount:        amount.MinorUnits,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transferQuoteResponse{
+		Amount: p.money(amount.MinorUnits, req.Currency),
+		Fee:    p.fee(fee, req.Currency),
+		Total:  p.money(amount.MinorUnits+fee.Amount, req.Currency),
+	})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteTransferAPI(t *testing.T) {
+	from := randomAccount()
+	from.Currency = util.USD
+	to := db.Account{ID: from.ID + 10, Owner: util.RandomOwner(), Currency: util.USD}
+	body := gin.H{"from_account_id": from.ID, "to_account_id": to.ID, "amount": "20", "currency": util.USD}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: from.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to.ID)).Times(1).Return(to, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(db.TransferParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 2000})).
+					Times(1).
+					Return(db.Fee{RuleID: 1, Flat: 25, Percentage: 20, Amount: 45}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferQuoteResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, int64(45), rsp.Fee.Amount.MinorUnits)
+				require.Equal(t, int64(25), rsp.Fee.Flat.MinorUnits)
+				require.Equal(t, int64(2045), rsp.Total.MinorUnits)
+			},
+		},
+		{
+			name:     "AccountNotOwned",
+			username: "unauthorized",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().QuoteFee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, codeForbidden)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/transfers/quote", bytes.NewReader(data))
+			addAuthorization(t, request, server.tokenMaker, tc.username, util.RoleCustomer)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
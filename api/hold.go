@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+)
+
+// holds expire after defaultHoldDuration unless the request asks for another expiry,
+// which can't be later than maxHoldDuration
+const (
+	defaultHoldDuration = 7 * 24 * time.Hour
+	maxHoldDuration     = 30 * 24 * time.Hour
+)
+
+var (
+	errHoldNotInvolved = newAPIError(http.StatusForbidden, codeForbidden, "hold doesn't involve an account of the authenticated user")
+	errHoldNotPayee    = newAPIError(http.StatusForbidden, codeForbidden, "hold can only be settled by the owner of its to account")
+)
+
+type holdResponse struct {
+	ID             int64      `json:"id"`
+	FromAccountID  int64      `json:"from_account_id"`
+	ToAccountID    int64      `json:"to_account_id"`
+	Amount         util.Money `json:"amount"`
+	Fee            util.Money `json:"fee"`
+	CapturedAmount util.Money `json:"captured_amount"`
+	Status         string     `json:"status"`
+	TransferID     *int64     `json:"transfer_id"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (p presenter) hold(hold db.Hold) holdResponse {
+	rsp := holdResponse{
+		ID:             hold.ID,
+		FromAccountID:  hold.FromAccountID,
+		ToAccountID:    hold.ToAccountID,
+		Amount:         p.money(hold.Amount, hold.Currency),
+		Fee:            p.money(hold.FeeAmount, hold.Currency),
+		CapturedAmount: p.money(hold.CapturedAmount, hold.Currency),
+		Status:         hold.Status,
+		ExpiresAt:      hold.ExpiresAt,
+		CreatedAt:      hold.CreatedAt,
+		UpdatedAt:      hold.UpdatedAt,
+	}
+	if hold.TransferID.Valid {
+		rsp.TransferID = &hold.TransferID.Int64
+	}
+	return rsp
+}
+
+type authorizeHoldRequest struct {
+	FromAccount int64     `json:"from_account_id" binding:"required,min=1"`
+	ToAccount   int64     `json:"to_account_id" binding:"required,min=1"`
+	Amount      string    `json:"amount" binding:"required"`
+	Currency    string    `json:"currency" binding:"required,currency"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// authorizeHold hold an amount of an account of the authenticated user for a later
+// capture by the owner of the to account
+func (server *Server) authorizeHold(ctx *gin.Context) {
+	var req authorizeHoldRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	if _, err := server.checkCurrency(ctx, "currency", req.Currency); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	amount, err := server.parseAmount(ctx, "amount", req.Amount, req.Currency)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := req.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(defaultHoldDuration)
+	}
+	if !expiresAt.After(now) || expiresAt.After(now.Add(maxHoldDuration)) {
+		writeError(ctx, invalidField("expires_at", "must be in the next 30 days"))
+		return
+	}
+
+	fromAccount, valid := isValidAccount(server, ctx, req.FromAccount, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, errAccountNotOwned)
+		return
+	}
+	if _, valid := getRequestAccount(server, ctx, req.ToAccount); !valid {
+		return
+	}
+
+	hold, err := server.db.AuthorizeTx(ctx, db.AuthorizeParams{
+		FromAccountID: req.FromAccount,
+		ToAccountID:   req.ToAccount,
+		Amount:        amount.MinorUnits,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	server.writeHold(ctx, hold)
+}
+
+type holdURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getHold return a hold to whoever can read either of its accounts
+func (server *Server) getHold(ctx *gin.Context) {
+	hold, ok := server.requestHold(ctx, false)
+	if !ok {
+		return
+	}
+
+	server.writeHold(ctx, hold)
+}
+
+type captureHoldRequest struct {
+	// Amount is the part of the hold to capture, the whole hold when empty
+	Amount string `json:"amount"`
+}
+
+type captureHoldResponse struct {
+	transferResultResponse
+	Hold holdResponse `json:"hold"`
+}
+
+// captureHold settle a pending hold, in full or in part, by the owner of its to account,
+// what is not captured is released
+func (server *Server) captureHold(ctx *gin.Context) {
+	var req captureHoldRequest
+	// the body is optional, a capture without one takes the whole hold
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	hold, ok := server.requestHold(ctx, true)
+	if !ok {
+		return
+	}
+
+	arg := db.CaptureHoldParams{HoldID: hold.ID}
+	if req.Amount != "" {
+		amount, err := server.parseAmount(ctx, "amount", req.Amount, hold.Currency)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		arg.Amount = amount.MinorUnits
+	}
+
+	result, err := server.db.CaptureHoldTx(ctx, arg)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	ctx.JSON(http.StatusOK, captureHoldResponse{
+		transferResultResponse: p.transferResult(result.TransferResult, authPayload),
+		Hold:                   p.hold(result.Hold),
+	})
+}
+
+// voidHold release a pending hold without moving funds, by the owner of its to account
+func (server *Server) voidHold(ctx *gin.Context) {
+	hold, ok := server.requestHold(ctx, true)
+	if !ok {
+		return
+	}
+
+	hold, err := server.db.VoidHoldTx(ctx, hold.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	server.writeHold(ctx, hold)
+}
+
+// requestHold return the hold of the url to the owner of its to account, or unless
+// payeeOnly to whoever can read either of its accounts, answering with an error otherwise
+func (server *Server) requestHold(ctx *gin.Context, payeeOnly bool) (db.Hold, bool) {
+	var uri holdURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, bindingError(err))
+		return db.Hold{}, false
+	}
+
+	hold, err := server.db.GetHold(ctx, uri.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return hold, false
+	}
+
+	accountIDs := []int64{hold.ToAccountID}
+	if !payeeOnly {
+		accountIDs = append(accountIDs, hold.FromAccountID)
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accountID := range accountIDs {
+		account, err := server.db.GetAccount(ctx, accountID)
+		if err != nil {
+			writeError(ctx, err)
+			return hold, false
+		}
+		if account.Owner == authPayload.Username || (!payeeOnly && canReadAccount(authPayload, account)) {
+			return hold, true
+		}
+	}
+
+	if payeeOnly {
+		writeError(ctx, errHoldNotPayee)
+	} else {
+		writeError(ctx, errHoldNotInvolved)
+	}
+	return hold, false
+}
+
+func (server *Server) writeHold(ctx *gin.Context, hold db.Hold) {
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p.hold(hold))
+}
+
+// runHoldExpiry release the expired holds every interval until ctx is done
+func (server *Server) runHoldExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		server.expireHolds(ctx, time.Now())
+	}
+}
+
+// expireHolds release the holds expired by now in batches until none is left and
+// return how many were released
+func (server *Server) expireHolds(ctx context.Context, now time.Time) int {
+	released := 0
+	for {
+		holds, err := server.db.ExpireHoldsTx(ctx, now, db.DefaultHoldExpiryBatchSize)
+		if err != nil {
+			log.Println("hold expiry failed:", err)
+			return released
+		}
+
+		released += len(holds)
+		if len(holds) < db.DefaultHoldExpiryBatchSize {
+			return released
+		}
+	}
+}
@@ -1,26 +1,82 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
 	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
 	"github.com/hamdysherif/simplebank/util"
 	"github.com/stretchr/testify/require"
 )
 
-func NewTestServer(t *testing.T, store db.Store) *Server {
+// NewTestServer create a server on top of the mocked store, tokens are
+// reported as neither revoked nor issued before a password change and the
+// seeded currencies are enabled unless the test stubs them first
+func NewTestServer(t *testing.T, store *mockdb.MockStore) *Server {
+	stubCurrencies(store)
+	store.
+		EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(false, nil)
+	store.
+		EXPECT().
+		GetUserTokensRevokedAt(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(time.Time{}, nil)
+
 	config := util.Config{
-		SemmetricKey:  util.RandomString(32),
-		TokenDuration: time.Minute,
+		SemmetricKey:         util.RandomString(32),
+		TokenDuration:        time.Minute,
+		RefreshTokenDuration: time.Hour,
 	}
 	server, err := NewServer(store, config)
 
 	require.NoError(t, err)
 	return server
 }
+
+// testCurrencies are the currencies the table is seeded with
+var testCurrencies = []db.Currency{
+	{Code: util.EGP, Exponent: 2, Enabled: true},
+	{Code: util.EUR, Exponent: 2, Enabled: true},
+	{Code: util.SAR, Exponent: 2, Enabled: true},
+	{Code: util.USD, Exponent: 2, Enabled: true},
+}
+
+// stubCurrencies answer ListCurrencies with testCurrencies
+func stubCurrencies(store *mockdb.MockStore) {
+	store.
+		EXPECT().
+		ListCurrencies(gomock.Any()).
+		AnyTimes().
+		Return(testCurrencies, nil)
+}
+
+// testPresenter render amounts the way a server with testCurrencies does
+func testPresenter() presenter {
+	p := presenter{exponents: make(map[string]int32)}
+	for _, currency := range testCurrencies {
+		p.exponents[currency.Code] = currency.Exponent
+	}
+	return p
+}
+
+// addAuthorization set a bearer token for username holding role on the request
+func addAuthorization(t *testing.T, request *http.Request, tokenMaker token.Maker, username string, role string) {
+	accessToken, _, err := tokenMaker.CreateToken(username, role, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	request.Header.Set("authorization", fmt.Sprintf("%s %s", "bearer", accessToken))
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
@@ -1,31 +1,33 @@
 package api
 
 import (
-	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
 	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
 )
 
-func Authentication(tokenMaker token.Maker) gin.HandlerFunc {
+// authorizationPayloadKey is the gin context key holding the verified *token.Payload
+const authorizationPayloadKey = "authpayload"
+
+func Authentication(tokenMaker token.Maker, revocations *revocationCache) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
-		token := ctx.Request.Header.Get("authorization")
+		header := ctx.Request.Header.Get("authorization")
 
-		tokenParts := strings.Fields(token)
+		tokenParts := strings.Fields(header)
 
 		if len(tokenParts) != 2 {
-			ctx.JSON(http.StatusUnauthorized, responseError(errors.New("invalid token")))
-			ctx.Abort()
+			abortWithError(ctx, errInvalidToken)
 			return
 		}
 
 		tokenType := tokenParts[0]
 		if strings.ToLower(tokenType) != "bearer" {
-			ctx.JSON(http.StatusUnauthorized, responseError(errors.New("unsupported token type")))
-			ctx.Abort()
+			abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidToken, "unsupported token type"))
 			return
 		}
 
@@ -34,13 +36,57 @@ func Authentication(tokenMaker token.Maker) gin.HandlerFunc {
 		payload, err := tokenMaker.VerifyToken(tokenPayload)
 
 		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, responseError(errors.New("invalid token")))
-			ctx.Abort()
+			abortWithError(ctx, errInvalidToken)
+			return
+		}
+
+		// refresh tokens live much longer and may only be traded for access tokens
+		if payload.Kind != token.AccessToken {
+			abortWithError(ctx, errInvalidToken)
+			return
+		}
+
+		revoked, err := revocations.isRevoked(ctx, payload)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		if revoked {
+			abortWithError(ctx, errTokenRevoked)
 			return
 		}
 
-		ctx.Set("authpayload", payload)
+		ctx.Set(authorizationPayloadKey, payload)
 
 		ctx.Next()
 	}
 }
+
+// RequireRoles allow the request only when the authenticated user holds one of roles,
+// it must run after Authentication
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !allowed[payload.Role] {
+			abortWithError(ctx, errInsufficientRole)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// canReadAccount report whether the user of payload may read account, customers only
+// read their own accounts while staff read every account as on GET /admin/accounts
+func canReadAccount(payload *token.Payload, account db.Account) bool {
+	switch payload.Role {
+	case util.RoleAdmin, util.RoleTeller, util.RoleAuditor:
+		return true
+	}
+	return account.Owner == payload.Username
+}
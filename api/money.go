@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+)
+
+// parseAmount parse the decimal amount of a request in currency, an amount that is not
+// a positive number with at most the decimals of the currency is reported on field
+func (server *Server) parseAmount(ctx context.Context, field string, value string, currency string) (util.Money, error) {
+	amount, err := server.parseMoney(ctx, field, value, currency)
+	if err == nil && amount.MinorUnits <= 0 {
+		return amount, invalidField(field, "must be greater than 0")
+	}
+	return amount, err
+}
+
+// parseMoney is parseAmount for values that may be zero or negative
+func (server *Server) parseMoney(ctx context.Context, field string, value string, currency string) (util.Money, error) {
+	cur, ok, err := server.currencies.get(ctx, currency)
+	if err != nil {
+		return util.Money{}, err
+	}
+	if !ok {
+		return util.Money{}, invalidField(field, "is not in a supported currency")
+	}
+
+	amount, err := util.ParseMoney(value, currency, cur.Exponent)
+	switch {
+	case errors.Is(err, util.ErrTooPrecise):
+		return amount, invalidField(field, "has more decimals than "+currency+" allows")
+	case err != nil:
+		return amount, invalidField(field, "must be a decimal number such as 12.50")
+	}
+	return amount, nil
+}
+
+// checkCurrency return the currency with code, reporting field when it is not supported
+func (server *Server) checkCurrency(ctx context.Context, field string, code string) (db.Currency, error) {
+	currency, ok, err := server.currencies.get(ctx, code)
+	if err != nil {
+		return currency, err
+	}
+	if !ok {
+		return currency, invalidField(field, "is not a supported currency")
+	}
+	return currency, nil
+}
+
+// invalidField report a single invalid request field
+func invalidField(field string, message string) *apiError {
+	apiErr := newAPIError(http.StatusBadRequest, codeInvalidRequest, "invalid request")
+	apiErr.Details = []fieldError{{Field: field, Message: message}}
+	return apiErr
+}
+
+// presenter render stored minor unit amounts as money of their currency
+type presenter struct {
+	exponents map[string]int32
+}
+
+// presenter read the exponents of the currencies table
+func (server *Server) presenter(ctx context.Context) (presenter, error) {
+	currencies, err := server.currencies.list(ctx)
+	if err != nil {
+		return presenter{}, err
+	}
+
+	exponents := make(map[string]int32, len(currencies))
+	for _, currency := range currencies {
+		exponents[currency.Code] = currency.Exponent
+	}
+	return presenter{exponents: exponents}, nil
+}
+
+func (p presenter) money(minorUnits int64, currency string) util.Money {
+	return util.NewMoney(minorUnits, currency, p.exponents[currency])
+}
+
+type accountResponse struct {
+	ID      int64      `json:"id"`
+	Owner   string     `json:"owner"`
+	Balance util.Money `json:"balance"`
+	// AvailableBalance is the balance less the pending holds
+	AvailableBalance util.Money `json:"available_balance"`
+	Currency         string     `json:"currency"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UserID           int64      `json:"user_id"`
+	Status           string     `json:"status"`
+	StatusReason     string     `json:"status_reason,omitempty"`
+	StatusChangedAt  time.Time  `json:"status_changed_at"`
+	ClosedAt         *time.Time `json:"closed_at,omitempty"`
+	// OverdraftLimit is how far below zero the balance can go, Headroom is what can
+	// still be spent within it, both are left out for the bank's own accounts
+	OverdraftLimit *util.Money `json:"overdraft_limit,omitempty"`
+	Headroom       *util.Money `json:"headroom,omitempty"`
+}
+
+func (p presenter) account(account db.Account) accountResponse {
+	rsp := accountResponse{
+		ID:               account.ID,
+		Owner:            account.Owner,
+		Balance:          p.money(account.Balance, account.Currency),
+		AvailableBalance: p.money(account.Balance-account.HoldAmount, account.Currency),
+		Currency:         account.Currency,
+		CreatedAt:        account.CreatedAt,
+		UserID:           account.UserID,
+		Status:           account.Status,
+		StatusReason:     account.StatusReason,
+		StatusChangedAt:  account.StatusChangedAt,
+	}
+	if account.ClosedAt.Valid {
+		rsp.ClosedAt = &account.ClosedAt.Time
+	}
+	if account.OverdraftLimit.Valid {
+		limit := p.money(account.OverdraftLimit.Int64, account.Currency)
+		headroom := p.money(headroom(account), account.Currency)
+		rsp.OverdraftLimit, rsp.Headroom = &limit, &headroom
+	}
+	return rsp
+}
+
+// headroom return what account can still spend, its available balance plus its
+// overdraft limit, without bound for the bank's own accounts
+func headroom(account db.Account) int64 {
+	if !account.OverdraftLimit.Valid {
+		return math.MaxInt64
+	}
+	return account.Balance - account.HoldAmount + account.OverdraftLimit.Int64
+}
+
+func (p presenter) accounts(accounts []db.Account) []accountResponse {
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = p.account(account)
+	}
+	return rsp
+}
+
+type entryResponse struct {
+	ID         int64      `json:"id"`
+	AccountID  int64      `json:"account_id"`
+	Amount     util.Money `json:"amount"`
+	CreatedAt  time.Time  `json:"created_at"`
+	TransferID *int64     `json:"transfer_id"`
+	Type       string     `json:"type"`
+}
+
+// entry render entry, currency is the currency of its account
+func (p presenter) entry(entry db.Entry, currency string) entryResponse {
+	rsp := entryResponse{
+		ID:        entry.ID,
+		AccountID: entry.AccountID,
+		Amount:    p.money(entry.Amount, currency),
+		CreatedAt: entry.CreatedAt,
+		Type:      entry.Type,
+	}
+	if entry.TransferID.Valid {
+		rsp.TransferID = &entry.TransferID.Int64
+	}
+	return rsp
+}
+
+type transferResponse struct {
+	ID            int64           `json:"id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        util.Money      `json:"amount"`
+	ToAmount      util.Money      `json:"to_amount"`
+	ExchangeRate  string          `json:"exchange_rate"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// transfer render transfer between accounts in fromCurrency and toCurrency
+func (p presenter) transfer(transfer db.Transfer, fromCurrency string, toCurrency string) transferResponse {
+	return transferResponse{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        p.money(transfer.Amount, fromCurrency),
+		ToAmount:      p.money(transfer.ToAmount, toCurrency),
+		ExchangeRate:  transfer.ExchangeRate,
+		Description:   transfer.Description,
+		Reference:     transfer.Reference,
+		Metadata:      transfer.Metadata,
+		CreatedAt:     transfer.CreatedAt,
+	}
+}
+
+type feeResponse struct {
+	Flat       util.Money `json:"flat"`
+	Percentage util.Money `json:"percentage"`
+	// Amount is what is charged, flat plus percentage held within the limits of the rule
+	Amount util.Money `json:"amount"`
+}
+
+// fee render fee, currency is the currency of the from account
+func (p presenter) fee(fee db.Fee, currency string) feeResponse {
+	return feeResponse{
+		Flat:       p.money(fee.Flat, currency),
+		Percentage: p.money(fee.Percentage, currency),
+		Amount:     p.money(fee.Amount, currency),
+	}
+}
+
+type transferResultResponse struct {
+	Transfer transferResponse `json:"transfer"`
+	// FromAccount and ToAccount are left out for callers who can't read them, the
+	// transfer still shows their ids and currencies
+	FromAccount *accountResponse `json:"from_account,omitempty"`
+	ToAccount   *accountResponse `json:"to_account,omitempty"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+	Fee         feeResponse      `json:"fee"`
+	FeeEntry    *entryResponse   `json:"fee_entry,omitempty"`
+}
+
+// transferResult render result for viewer, the accounts viewer can't read are left out
+func (p presenter) transferResult(result db.TransferResult, viewer *token.Payload) transferResultResponse {
+	from, to := result.FromAccount.Currency, result.ToAccount.Currency
+	rsp := transferResultResponse{
+		Transfer:  p.transfer(result.Transfer, from, to),
+		FromEntry: p.entry(result.FromEntry, from),
+		ToEntry:   p.entry(result.ToEntry, to),
+		Fee:       p.fee(result.Fee, from),
+	}
+	if canReadAccount(viewer, result.FromAccount) {
+		account := p.account(result.FromAccount)
+		rsp.FromAccount = &account
+	}
+	if canReadAccount(viewer, result.ToAccount) {
+		account := p.account(result.ToAccount)
+		rsp.ToAccount = &account
+	}
+	if result.Fee.Amount != 0 {
+		feeEntry := p.entry(result.FeeEntry, from)
+		rsp.FeeEntry = &feeEntry
+	}
+	return rsp
+}
+
+type transferJournalResponse struct {
+	Transfer transferResponse `json:"transfer"`
+	Entries  []entryResponse  `json:"entries"`
+}
+
+// journal render journal, accounts must hold every account the journal touches
+func (p presenter) journal(journal db.TransferJournal, accounts map[int64]db.Account) transferJournalResponse {
+	rsp := transferJournalResponse{
+		Transfer: p.transfer(journal.Transfer,
+			accounts[journal.Transfer.FromAccountID].Currency,
+			accounts[journal.Transfer.ToAccountID].Currency),
+		Entries: make([]entryResponse, len(journal.Entries)),
+	}
+	for i, entry := range journal.Entries {
+		rsp.Entries[i] = p.entry(entry, accounts[entry.AccountID].Currency)
+	}
+	return rsp
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+)
+
+// reconcileLedger run a ledger reconciliation on demand and return its report
+func (server *Server) reconcileLedger(ctx *gin.Context) {
+	report, err := db.ReconcileLedger(ctx, server.db, db.DefaultReconciliationBatchSize)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// runReconciliation reconcile the ledger every interval until ctx is done and log
+// the discrepancies found
+func (server *Server) runReconciliation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := db.ReconcileLedger(ctx, server.db, db.DefaultReconciliationBatchSize)
+		if err != nil {
+			log.Println("ledger reconciliation failed:", err)
+			continue
+		}
+
+		log.Printf("ledger reconciliation checked %d accounts and %d transfers, found %d discrepancies",
+			report.AccountsChecked, report.TransfersChecked, len(report.Discrepancies))
+		for _, discrepancy := range report.Discrepancies {
+			log.Printf("ledger discrepancy %s account=%d transfer=%d: %s",
+				discrepancy.Kind, discrepancy.AccountID, discrepancy.TransferID, discrepancy.Detail)
+		}
+	}
+}
+
+// runPostingSweep add the pending postings of the cash, fees and fx accounts to their
+// balances every interval until ctx is done
+func (server *Server) runPostingSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := server.db.ApplyPendingPostings(ctx); err != nil {
+			log.Println("posting sweep failed:", err)
+		}
+	}
+}
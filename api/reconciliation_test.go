@@ -0,0 +1,114 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileLedgerAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, util.RandomOwner(), util.RoleAuditor)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ListAccountLedgerTotals(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListAccountLedgerTotalsRow{
+						{ID: 1, Balance: 100, EntriesTotal: 100},
+						{ID: 2, Balance: 70, EntriesTotal: 50},
+						{ID: 3, Balance: 40, EntriesTotal: 45, PendingTotal: 5},
+					}, nil)
+				store.
+					EXPECT().
+					ListTransferEntryCounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListTransferEntryCountsRow{
+						{ID: 1, FromAccountID: 1, ToAccountID: 2, Amount: 20, FromEntries: 1, ToEntries: 0},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var report db.ReconciliationReport
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+				require.Equal(t, 3, report.AccountsChecked)
+				require.Equal(t, 1, report.TransfersChecked)
+				require.Len(t, report.Discrepancies, 2)
+				require.Equal(t, db.DiscrepancyBalanceMismatch, report.Discrepancies[0].Kind)
+				require.Equal(t, int64(2), report.Discrepancies[0].AccountID)
+				require.Equal(t, db.DiscrepancyUnmatchedEntries, report.Discrepancies[1].Kind)
+				require.Equal(t, int64(1), report.Discrepancies[1].TransferID)
+			},
+		},
+		{
+			name: "Teller",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, util.RandomOwner(), util.RoleTeller)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ListAccountLedgerTotals(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalServerError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, util.RandomOwner(), util.RoleAdmin)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ListAccountLedgerTotals(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/ledger/reconciliation", nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
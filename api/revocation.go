@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+)
+
+// revocationCheckTTL is how long a "not revoked" lookup is trusted before
+// the database is asked again, revocations made by this server apply immediately
+const revocationCheckTTL = 30 * time.Second
+
+// tokensRevocation is a cached users.tokens_revoked_at lookup
+type tokensRevocation struct {
+	revokedAt    time.Time
+	checkedUntil time.Time
+}
+
+// revocationCache keep the hot path of the Authentication middleware off the
+// database by remembering revoked token ids until they expire, recent
+// negative lookups and the times users tokens were revoked for revocationCheckTTL
+type revocationCache struct {
+	store db.Store
+	ttl   time.Duration
+
+	mu              sync.RWMutex
+	revoked         map[uuid.UUID]time.Time     // token id -> token expiry
+	checked         map[uuid.UUID]time.Time     // token id -> negative lookup expiry
+	userRevocations map[string]tokensRevocation // username -> tokens revocation time
+	lastSweep       time.Time
+}
+
+func newRevocationCache(store db.Store, ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		store:           store,
+		ttl:             ttl,
+		revoked:         make(map[uuid.UUID]time.Time),
+		checked:         make(map[uuid.UUID]time.Time),
+		userRevocations: make(map[string]tokensRevocation),
+		lastSweep:       time.Now(),
+	}
+}
+
+// isRevoked report whether the token with payload has been revoked, either
+// explicitly or by a password change or session revocation of its user after it was issued
+func (cache *revocationCache) isRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	revoked, err := cache.isTokenRevoked(ctx, payload)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	revokedAt, err := cache.tokensRevokedAt(ctx, payload.Username)
+	if err == sql.ErrNoRows {
+		// tokens of a user that no longer exists are never valid
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return payload.IssuedAt.Before(revokedAt), nil
+}
+
+// isTokenRevoked report whether the token id of payload has been revoked
+func (cache *revocationCache) isTokenRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	now := time.Now()
+
+	cache.mu.RLock()
+	_, revoked := cache.revoked[payload.ID]
+	checkedUntil, checked := cache.checked[payload.ID]
+	cache.mu.RUnlock()
+
+	if revoked {
+		return true, nil
+	}
+	if checked && now.Before(checkedUntil) {
+		return false, nil
+	}
+
+	revoked, err := cache.store.IsTokenRevoked(ctx, payload.ID)
+	if err != nil {
+		return false, err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if revoked {
+		cache.revoked[payload.ID] = payload.ExpireAt
+		delete(cache.checked, payload.ID)
+	} else {
+		cache.checked[payload.ID] = now.Add(cache.ttl)
+	}
+	cache.sweep(now)
+
+	return revoked, nil
+}
+
+// revoke persist the revocation of payload's token and apply it to the cache
+func (cache *revocationCache) revoke(ctx context.Context, payload *token.Payload) error {
+	err := cache.store.RevokeToken(ctx, db.RevokeTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpireAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.markRevoked(payload.ID, payload.ExpireAt)
+	return nil
+}
+
+// markRevoked apply an already persisted revocation to the cache
+func (cache *revocationCache) markRevoked(id uuid.UUID, expiresAt time.Time) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.revoked[id] = expiresAt
+	delete(cache.checked, id)
+}
+
+// tokensRevokedAt return when the tokens of username were last revoked
+func (cache *revocationCache) tokensRevokedAt(ctx context.Context, username string) (time.Time, error) {
+	now := time.Now()
+
+	cache.mu.RLock()
+	revocation, ok := cache.userRevocations[username]
+	cache.mu.RUnlock()
+
+	if ok && now.Before(revocation.checkedUntil) {
+		return revocation.revokedAt, nil
+	}
+
+	revokedAt, err := cache.store.GetUserTokensRevokedAt(ctx, username)
+	if err != nil {
+		return revokedAt, err
+	}
+
+	cache.setTokensRevokedAt(username, revokedAt)
+	return revokedAt, nil
+}
+
+// setTokensRevokedAt record a revocation of the tokens of username made through this server
+func (cache *revocationCache) setTokensRevokedAt(username string, revokedAt time.Time) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.userRevocations[username] = tokensRevocation{
+		revokedAt:    revokedAt,
+		checkedUntil: time.Now().Add(cache.ttl),
+	}
+}
+
+// sweep drop entries that can no longer matter, it must be called with mu held
+func (cache *revocationCache) sweep(now time.Time) {
+	if now.Sub(cache.lastSweep) < cache.ttl {
+		return
+	}
+
+	for id, expiresAt := range cache.revoked {
+		if now.After(expiresAt) {
+			delete(cache.revoked, id)
+		}
+	}
+	for id, checkedUntil := range cache.checked {
+		if now.After(checkedUntil) {
+			delete(cache.checked, id)
+		}
+	}
+	for username, revocation := range cache.userRevocations {
+		if now.After(revocation.checkedUntil) {
+			delete(cache.userRevocations, username)
+		}
+	}
+	cache.lastSweep = now
+}
+
+// runRevokedTokenSweep delete the revoked token ids that expired every interval,
+// an expired token is rejected without looking at revoked_tokens
+func (server *Server) runRevokedTokenSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := server.db.DeleteExpiredRevokedTokens(ctx); err != nil {
+			log.Println("revoked token sweep failed:", err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newRevocationTestServer(t *testing.T, store *mockdb.MockStore) *Server {
+	stubCurrencies(store)
+	config := util.Config{
+		SemmetricKey:         util.RandomString(32),
+		TokenDuration:        time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+	server, err := NewServer(store, config)
+	require.NoError(t, err)
+	return server
+}
+
+func TestAuthenticationRevokedToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newRevocationTestServer(t, store)
+
+	username := util.RandomOwner()
+	accessToken, payload, err := server.tokenMaker.CreateToken(username, util.RoleCustomer, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	// a revoked token is looked up once and then served from the cache
+	store.
+		EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Eq(payload.ID)).
+		Times(1).
+		Return(true, nil)
+	store.
+		EXPECT().
+		ListAccountsByOwner(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	for i := 0; i < 2; i++ {
+		request, err := http.NewRequest(http.MethodGet, "/accounts?page=1&size=5", nil)
+		require.NoError(t, err)
+		request.Header.Set("authorization", "bearer "+accessToken)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+}
+
+func TestAuthenticationCachesNegativeLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newRevocationTestServer(t, store)
+
+	username := util.RandomOwner()
+	accessToken, payload, err := server.tokenMaker.CreateToken(username, util.RoleCustomer, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	store.
+		EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Eq(payload.ID)).
+		Times(1).
+		Return(false, nil)
+	store.
+		EXPECT().
+		GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(username)).
+		Times(1).
+		Return(payload.IssuedAt.Add(-time.Hour), nil)
+	store.
+		EXPECT().
+		ListAccountsByOwner(gomock.Any(), gomock.Any()).
+		Times(3)
+
+	for i := 0; i < 3; i++ {
+		request, err := http.NewRequest(http.MethodGet, "/accounts?page=1&size=5", nil)
+		require.NoError(t, err)
+		request.Header.Set("authorization", "bearer "+accessToken)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+}
+
+func TestAuthenticationTokenIssuedBeforePasswordChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newRevocationTestServer(t, store)
+
+	username := util.RandomOwner()
+	accessToken, payload, err := server.tokenMaker.CreateToken(username, util.RoleCustomer, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	store.
+		EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Eq(payload.ID)).
+		Times(1).
+		Return(false, nil)
+	store.
+		EXPECT().
+		GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(username)).
+		Times(1).
+		Return(payload.IssuedAt.Add(time.Second), nil)
+	store.
+		EXPECT().
+		ListAccountsByOwner(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request, err := http.NewRequest(http.MethodGet, "/accounts?page=1&size=5", nil)
+	require.NoError(t, err)
+	request.Header.Set("authorization", "bearer "+accessToken)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestAuthenticationAfterSessionRevocation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newRevocationTestServer(t, store)
+
+	username := util.RandomOwner()
+	accessToken, payload, err := server.tokenMaker.CreateToken(username, util.RoleCustomer, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	admin := util.RandomOwner()
+	adminToken, adminPayload, err := server.tokenMaker.CreateToken(admin, util.RoleAdmin, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	store.
+		EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(false, nil)
+	store.
+		EXPECT().
+		GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(admin)).
+		Times(1).
+		Return(adminPayload.IssuedAt.Add(-time.Hour), nil)
+	// the revocation made by this server replaces the cached lookup
+	store.
+		EXPECT().
+		GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(username)).
+		Times(1).
+		Return(payload.IssuedAt.Add(-time.Hour), nil)
+	store.
+		EXPECT().
+		RevokeUserSessionsTx(gomock.Any(), gomock.Eq(username)).
+		Times(1).
+		Return(db.RevokeUserSessionsResult{RevokedAt: payload.IssuedAt.Add(time.Second)}, nil)
+	store.
+		EXPECT().
+		ListAccountsByOwner(gomock.Any(), gomock.Any()).
+		Times(1)
+
+	listAccounts := func() int {
+		request, err := http.NewRequest(http.MethodGet, "/accounts?page=1&size=5", nil)
+		require.NoError(t, err)
+		request.Header.Set("authorization", "bearer "+accessToken)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusOK, listAccounts())
+
+	url := fmt.Sprintf("/admin/users/%s/revoke_sessions", username)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	request.Header.Set("authorization", "bearer "+adminToken)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.Equal(t, http.StatusUnauthorized, listAccounts())
+}
+
+func TestAuthenticationAfterRoleChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newRevocationTestServer(t, store)
+
+	demoted := randomUser("secret")
+	demoted.Role = util.RoleCustomer
+	demotedToken, demotedPayload, err := server.tokenMaker.CreateToken(demoted.Username, util.RoleAdmin, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	admin := util.RandomOwner()
+	adminToken, adminPayload, err := server.tokenMaker.CreateToken(admin, util.RoleAdmin, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	store.
+		EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(false, nil)
+	store.
+		EXPECT().
+		GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(admin)).
+		Times(1).
+		Return(adminPayload.IssuedAt.Add(-time.Hour), nil)
+	store.
+		EXPECT().
+		GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(demoted.Username)).
+		Times(1).
+		Return(demotedPayload.IssuedAt.Add(-time.Hour), nil)
+	store.
+		EXPECT().
+		UpdateUserRole(gomock.Any(), gomock.Eq(db.UpdateUserRoleParams{Username: demoted.Username, Role: util.RoleCustomer})).
+		Times(1).
+		Return(demoted, nil)
+	store.
+		EXPECT().
+		RevokeUserSessionsTx(gomock.Any(), gomock.Eq(demoted.Username)).
+		Times(1).
+		Return(db.RevokeUserSessionsResult{RevokedAt: demotedPayload.IssuedAt.Add(time.Second)}, nil)
+	store.
+		EXPECT().
+		ListAccounts(gomock.Any(), gomock.Any()).
+		Times(1)
+
+	listAllAccounts := func() int {
+		request, err := http.NewRequest(http.MethodGet, "/admin/accounts?page=1&size=5", nil)
+		require.NoError(t, err)
+		request.Header.Set("authorization", "bearer "+demotedToken)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusOK, listAllAccounts())
+
+	body, err := json.Marshal(map[string]string{"role": util.RoleCustomer})
+	require.NoError(t, err)
+	url := fmt.Sprintf("/admin/users/%s/role", demoted.Username)
+	request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set("authorization", "bearer "+adminToken)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// the admin token issued before the demotion no longer works
+	require.Equal(t, http.StatusUnauthorized, listAllAccounts())
+}
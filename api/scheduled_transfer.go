@@ -0,0 +1,428 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+)
+
+var (
+	errScheduleNotOwned = newAPIError(http.StatusForbidden, codeForbidden, "scheduled transfer doesn't belong to the authenticated user")
+	errScheduleClosed   = newAPIError(http.StatusConflict, codeScheduleClosed, "scheduled transfer is completed or cancelled")
+	errScheduleChanged  = newAPIError(http.StatusConflict, codeScheduleChanged, "scheduled transfer was run or changed while it was being updated, try again")
+)
+
+type scheduledTransferResponse struct {
+	ID            int64      `json:"id"`
+	Owner         string     `json:"owner"`
+	FromAccountID int64      `json:"from_account_id"`
+	ToAccountID   int64      `json:"to_account_id"`
+	Amount        util.Money `json:"amount"`
+	Frequency     string     `json:"frequency"`
+	StartsAt      time.Time  `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at"`
+	NextRunAt     time.Time  `json:"next_run_at"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (p presenter) scheduledTransfer(schedule db.ScheduledTransfer) scheduledTransferResponse {
+	rsp := scheduledTransferResponse{
+		ID:            schedule.ID,
+		Owner:         schedule.Owner,
+		FromAccountID: schedule.FromAccountID,
+		ToAccountID:   schedule.ToAccountID,
+		Amount:        p.money(schedule.Amount, schedule.Currency),
+		Frequency:     schedule.Frequency,
+		StartsAt:      schedule.StartsAt,
+		NextRunAt:     schedule.NextRunAt,
+		Status:        schedule.Status,
+		CreatedAt:     schedule.CreatedAt,
+	}
+	if schedule.EndsAt.Valid {
+		rsp.EndsAt = &schedule.EndsAt.Time
+	}
+	return rsp
+}
+
+type scheduledTransferRunResponse struct {
+	ID           int64     `json:"id"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Status       string    `json:"status"`
+	TransferID   *int64    `json:"transfer_id"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newScheduledTransferRunResponse(run db.ScheduledTransferRun) scheduledTransferRunResponse {
+	rsp := scheduledTransferRunResponse{
+		ID:           run.ID,
+		ScheduledFor: run.ScheduledFor,
+		Status:       run.Status,
+		Error:        run.Error,
+		CreatedAt:    run.CreatedAt,
+	}
+	if run.TransferID.Valid {
+		rsp.TransferID = &run.TransferID.Int64
+	}
+	return rsp
+}
+
+type createScheduledTransferRequest struct {
+	FromAccount int64      `json:"from_account_id" binding:"required,min=1"`
+	ToAccount   int64      `json:"to_account_id" binding:"required,min=1"`
+	Amount      string     `json:"amount" binding:"required"`
+	Currency    string     `json:"currency" binding:"required,currency"`
+	Frequency   string     `json:"frequency" binding:"required,oneof=once daily weekly monthly"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+}
+
+// createScheduledTransfer schedule a transfer from an account of the authenticated
+// user, a schedule without starts_at makes its first run right away
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	if _, err := server.checkCurrency(ctx, "currency", req.Currency); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	amount, err := server.parseAmount(ctx, "amount", req.Amount, req.Currency)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	now := time.Now()
+	startsAt := req.StartsAt
+	if startsAt.IsZero() {
+		startsAt = now
+	}
+	if startsAt.Before(now) {
+		writeError(ctx, invalidField("starts_at", "must not be in the past"))
+		return
+	}
+
+	var endsAt sql.NullTime
+	if req.EndsAt != nil {
+		if !req.EndsAt.After(startsAt) {
+			writeError(ctx, invalidField("ends_at", "must be after starts_at"))
+			return
+		}
+		endsAt = sql.NullTime{Time: *req.EndsAt, Valid: true}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	fromAccount, valid := isValidAccount(server, ctx, req.FromAccount, req.Currency)
+	if !valid {
+		return
+	}
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, errAccountNotOwned)
+		return
+	}
+	if _, valid := getRequestAccount(server, ctx, req.ToAccount); !valid {
+		return
+	}
+
+	schedule, err := server.db.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccount,
+		ToAccountID:   req.ToAccount,
+		Amount:        amount.MinorUnits,
+		Currency:      amount.Currency,
+		Frequency:     req.Frequency,
+		StartsAt:      startsAt,
+		EndsAt:        endsAt,
+		NextRunAt:     startsAt,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	server.writeScheduledTransfer(ctx, schedule)
+}
+
+type listScheduledTransfersRequest struct {
+	Page int32 `form:"page" binding:"required,min=1"`
+	Size int32 `form:"size" binding:"required,min=5,max=20"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	schedules, err := server.db.ListScheduledTransfersByOwner(ctx, db.ListScheduledTransfersByOwnerParams{
+		Owner:  authPayload.Username,
+		Offset: (req.Page - 1) * req.Size,
+		Limit:  req.Size,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	rsp := make([]scheduledTransferResponse, len(schedules))
+	for i, schedule := range schedules {
+		rsp[i] = p.scheduledTransfer(schedule)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type scheduledTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	schedule, ok := server.ownedScheduledTransfer(ctx)
+	if !ok {
+		return
+	}
+
+	server.writeScheduledTransfer(ctx, schedule)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount *string    `json:"amount"`
+	EndsAt *time.Time `json:"ends_at"`
+	Status string     `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+// updateScheduledTransfer change the amount or end of a schedule, or pause and resume
+// it, a resumed schedule skips the runs it missed while paused
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	schedule, ok := server.ownedScheduledTransfer(ctx)
+	if !ok {
+		return
+	}
+	if !scheduleOpen(schedule) {
+		writeError(ctx, errScheduleClosed)
+		return
+	}
+
+	arg := db.UpdateOpenScheduledTransferParams{
+		ID:            schedule.ID,
+		Amount:        schedule.Amount,
+		EndsAt:        schedule.EndsAt,
+		NextRunAt:     schedule.NextRunAt,
+		Status:        schedule.Status,
+		SeenStatus:    schedule.Status,
+		SeenNextRunAt: schedule.NextRunAt,
+	}
+
+	if req.Amount != nil {
+		amount, err := server.parseAmount(ctx, "amount", *req.Amount, schedule.Currency)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		arg.Amount = amount.MinorUnits
+	}
+
+	if req.Status != "" {
+		now := time.Now()
+		if req.Status == db.ScheduleStatusActive && schedule.Status == db.ScheduleStatusPaused && arg.NextRunAt.Before(now) {
+			// a one off schedule resumed after its time runs right away
+			if next, ok := db.NextScheduledRun(schedule.Frequency, schedule.StartsAt, now); ok {
+				arg.NextRunAt = next
+			}
+		}
+		arg.Status = req.Status
+	}
+
+	if req.EndsAt != nil {
+		if req.EndsAt.Before(arg.NextRunAt) {
+			writeError(ctx, invalidField("ends_at", "must not be before the next run"))
+			return
+		}
+		arg.EndsAt = sql.NullTime{Time: *req.EndsAt, Valid: true}
+	}
+
+	server.updateOpenSchedule(ctx, arg)
+}
+
+// cancelScheduledTransfer stop a schedule for good, its runs are kept
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	schedule, ok := server.ownedScheduledTransfer(ctx)
+	if !ok {
+		return
+	}
+	if !scheduleOpen(schedule) {
+		writeError(ctx, errScheduleClosed)
+		return
+	}
+
+	server.updateOpenSchedule(ctx, db.UpdateOpenScheduledTransferParams{
+		ID:            schedule.ID,
+		Amount:        schedule.Amount,
+		EndsAt:        schedule.EndsAt,
+		NextRunAt:     schedule.NextRunAt,
+		Status:        db.ScheduleStatusCancelled,
+		SeenStatus:    schedule.Status,
+		SeenNextRunAt: schedule.NextRunAt,
+	})
+}
+
+// updateOpenSchedule write arg over the schedule only if its status and next run are
+// still the ones the handler read, so a change can't undo a run the scheduler made in
+// the meantime, answering with errScheduleChanged otherwise
+func (server *Server) updateOpenSchedule(ctx *gin.Context, arg db.UpdateOpenScheduledTransferParams) {
+	schedule, err := server.db.UpdateOpenScheduledTransfer(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(ctx, errScheduleChanged)
+			return
+		}
+		writeError(ctx, err)
+		return
+	}
+
+	server.writeScheduledTransfer(ctx, schedule)
+}
+
+type listScheduledTransferRunsRequest struct {
+	Page int32 `form:"page" binding:"required,min=1"`
+	Size int32 `form:"size" binding:"required,min=5,max=20"`
+}
+
+// listScheduledTransferRuns list the outcome of each run of a schedule, latest first
+func (server *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var req listScheduledTransferRunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	schedule, ok := server.ownedScheduledTransfer(ctx)
+	if !ok {
+		return
+	}
+
+	runs, err := server.db.ListScheduledTransferRuns(ctx, db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: schedule.ID,
+		Offset:              (req.Page - 1) * req.Size,
+		Limit:               req.Size,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	rsp := make([]scheduledTransferRunResponse, len(runs))
+	for i, run := range runs {
+		rsp[i] = newScheduledTransferRunResponse(run)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// ownedScheduledTransfer return the schedule of the url, answering with an error when
+// it can't be read or isn't owned by the authenticated user
+func (server *Server) ownedScheduledTransfer(ctx *gin.Context) (db.ScheduledTransfer, bool) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, bindingError(err))
+		return db.ScheduledTransfer{}, false
+	}
+
+	schedule, err := server.db.GetScheduledTransfer(ctx, uri.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return schedule, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if schedule.Owner != authPayload.Username {
+		writeError(ctx, errScheduleNotOwned)
+		return schedule, false
+	}
+	return schedule, true
+}
+
+// scheduleOpen report whether a schedule can still be changed
+func scheduleOpen(schedule db.ScheduledTransfer) bool {
+	return schedule.Status == db.ScheduleStatusActive || schedule.Status == db.ScheduleStatusPaused
+}
+
+func (server *Server) writeScheduledTransfer(ctx *gin.Context, schedule db.ScheduledTransfer) {
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p.scheduledTransfer(schedule))
+}
+
+// runScheduledTransfers make the due scheduled transfers every interval until ctx is
+// done, several servers can run it against one database
+func (server *Server) runScheduledTransfers(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		server.runDueScheduledTransfers(ctx, time.Now())
+	}
+}
+
+// runDueScheduledTransfers run the schedules due at now one by one until none is left
+// and return how many ran, a schedule that failed is moved on by the store so the
+// others still run
+func (server *Server) runDueScheduledTransfers(ctx context.Context, now time.Time) int {
+	for runs := 0; ; runs++ {
+		result, err := server.db.RunScheduledTransferTx(ctx, now)
+		if err == db.ErrNoScheduledTransferDue {
+			return runs
+		}
+		if err != nil {
+			log.Println("scheduled transfer run failed:", err)
+			return runs
+		}
+
+		run := result.Run
+		if result.Cause != nil {
+			log.Printf("scheduled transfer %d due at %s failed: %s", result.Schedule.ID, run.ScheduledFor, result.Cause)
+			continue
+		}
+		if run.Status == db.RunStatusFailed {
+			log.Printf("scheduled transfer %d due at %s was rejected: %s", run.ScheduledTransferID, run.ScheduledFor, run.Error)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	from := randomAccount()
+	to := randomAccount()
+	to.ID = from.ID + 10
+	startsAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	body := func(changes gin.H) gin.H {
+		b := gin.H{
+			"from_account_id": from.ID,
+			"to_account_id":   to.ID,
+			"amount":          "12.50",
+			"currency":        from.Currency,
+			"frequency":       db.FrequencyMonthly,
+			"starts_at":       startsAt,
+		}
+		for key, value := range changes {
+			b[key] = value
+		}
+		return b
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body(nil),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, from.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to.ID)).Times(1).Return(to, nil)
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						require.Equal(t, from.Owner, arg.Owner)
+						require.Equal(t, int64(1250), arg.Amount)
+						require.Equal(t, db.FrequencyMonthly, arg.Frequency)
+						require.True(t, startsAt.Equal(arg.StartsAt))
+						require.True(t, startsAt.Equal(arg.NextRunAt))
+						require.False(t, arg.EndsAt.Valid)
+
+						return db.ScheduledTransfer{
+							ID:            1,
+							Owner:         arg.Owner,
+							FromAccountID: arg.FromAccountID,
+							ToAccountID:   arg.ToAccountID,
+							Amount:        arg.Amount,
+							Currency:      arg.Currency,
+							Frequency:     arg.Frequency,
+							StartsAt:      arg.StartsAt,
+							NextRunAt:     arg.NextRunAt,
+							Status:        db.ScheduleStatusActive,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got scheduledTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, "12.50", got.Amount.Decimal())
+				require.Equal(t, db.ScheduleStatusActive, got.Status)
+				require.Nil(t, got.EndsAt)
+			},
+		},
+		{
+			name: "InvalidFrequency",
+			body: body(gin.H{"frequency": "hourly"}),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, from.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeInvalidRequest)
+			},
+		},
+		{
+			name: "StartsInThePast",
+			body: body(gin.H{"starts_at": time.Now().Add(-time.Hour)}),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, from.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeInvalidRequest)
+			},
+		},
+		{
+			name: "EndsBeforeStart",
+			body: body(gin.H{"ends_at": startsAt.Add(-time.Minute)}),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, from.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeInvalidRequest)
+			},
+		},
+		{
+			name: "AccountNotOwned",
+			body: body(nil),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "unauthorized", util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, codeForbidden)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			body:      body(nil),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/scheduled_transfers", bytes.NewReader(data))
+			tc.setupAuth(t, request, server.tokenMaker)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateScheduledTransferAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	startsAt := time.Now().Add(-30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	schedule := db.ScheduledTransfer{
+		ID:            util.RandomInt(1, 100),
+		Owner:         owner,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        1000,
+		Currency:      util.USD,
+		Frequency:     db.FrequencyDaily,
+		StartsAt:      startsAt,
+		NextRunAt:     startsAt,
+		Status:        db.ScheduleStatusPaused,
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "ResumeSkipsMissedRuns",
+			method:   http.MethodPatch,
+			body:     gin.H{"status": db.ScheduleStatusActive, "amount": "20"},
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(schedule, nil)
+				store.EXPECT().
+					UpdateOpenScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateOpenScheduledTransferParams) (db.ScheduledTransfer, error) {
+						require.Equal(t, schedule.Status, arg.SeenStatus)
+						require.Equal(t, schedule.NextRunAt, arg.SeenNextRunAt)
+						require.Equal(t, db.ScheduleStatusActive, arg.Status)
+						require.Equal(t, int64(2000), arg.Amount)
+						require.True(t, arg.NextRunAt.After(time.Now()))
+
+						updated := schedule
+						updated.Amount = arg.Amount
+						updated.NextRunAt = arg.NextRunAt
+						updated.Status = arg.Status
+						return updated, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidStatus",
+			method:   http.MethodPatch,
+			body:     gin.H{"status": db.ScheduleStatusCompleted},
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateOpenScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeInvalidRequest)
+			},
+		},
+		{
+			name:     "Cancel",
+			method:   http.MethodDelete,
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				cancelled := schedule
+				cancelled.Status = db.ScheduleStatusCancelled
+
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(schedule, nil)
+				store.EXPECT().
+					UpdateOpenScheduledTransfer(gomock.Any(), gomock.Eq(db.UpdateOpenScheduledTransferParams{
+						ID:            schedule.ID,
+						Amount:        schedule.Amount,
+						EndsAt:        schedule.EndsAt,
+						NextRunAt:     schedule.NextRunAt,
+						Status:        db.ScheduleStatusCancelled,
+						SeenStatus:    schedule.Status,
+						SeenNextRunAt: schedule.NextRunAt,
+					})).
+					Times(1).
+					Return(cancelled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "RanMeanwhile",
+			method:   http.MethodDelete,
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(schedule, nil)
+				store.EXPECT().
+					UpdateOpenScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, codeScheduleChanged)
+			},
+		},
+		{
+			name:     "Closed",
+			method:   http.MethodDelete,
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				completed := schedule
+				completed.Status = db.ScheduleStatusCompleted
+
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(completed, nil)
+				store.EXPECT().UpdateOpenScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, codeScheduleClosed)
+			},
+		},
+		{
+			name:     "NotOwned",
+			method:   http.MethodPatch,
+			body:     gin.H{"status": db.ScheduleStatusPaused},
+			username: "unauthorized",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(schedule, nil)
+				store.EXPECT().UpdateOpenScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, codeForbidden)
+			},
+		},
+		{
+			name:     "NotFound",
+			method:   http.MethodDelete,
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(schedule.ID)).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorCode(t, recorder, codeNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+			}
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", schedule.ID)
+			request := httptest.NewRequest(tc.method, url, &body)
+			addAuthorization(t, request, server.tokenMaker, tc.username, util.RoleCustomer)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRunDueScheduledTransfers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := NewTestServer(t, store)
+	now := time.Now()
+
+	gomock.InOrder(
+		store.EXPECT().RunScheduledTransferTx(gomock.Any(), gomock.Eq(now)).
+			Return(db.ScheduledTransferRunResult{Run: db.ScheduledTransferRun{Status: db.RunStatusSucceeded}}, nil),
+		store.EXPECT().RunScheduledTransferTx(gomock.Any(), gomock.Eq(now)).
+			Return(db.ScheduledTransferRunResult{Run: db.ScheduledTransferRun{Status: db.RunStatusFailed, Error: "insufficient funds"}}, nil),
+		// a schedule that failed for an internal error doesn't stop the others
+		store.EXPECT().RunScheduledTransferTx(gomock.Any(), gomock.Eq(now)).
+			Return(db.ScheduledTransferRunResult{Run: db.ScheduledTransferRun{Status: db.RunStatusFailed}, Cause: sql.ErrConnDone}, nil),
+		store.EXPECT().RunScheduledTransferTx(gomock.Any(), gomock.Eq(now)).
+			Return(db.ScheduledTransferRunResult{Run: db.ScheduledTransferRun{Status: db.RunStatusSucceeded}}, nil),
+		store.EXPECT().RunScheduledTransferTx(gomock.Any(), gomock.Eq(now)).
+			Return(db.ScheduledTransferRunResult{}, db.ErrNoScheduledTransferDue),
+	)
+
+	require.Equal(t, 4, server.runDueScheduledTransfers(context.Background(), now))
+}
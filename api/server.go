@@ -1,30 +1,43 @@
 package api
 
 import (
+	"context"
+	"expvar"
 	"fmt"
+	"log"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt"
 	db "github.com/hamdysherif/simplebank/db/sqlc"
 	"github.com/hamdysherif/simplebank/token"
 	"github.com/hamdysherif/simplebank/util"
 )
 
 type Server struct {
-	db         db.Store
-	router     *gin.Engine
-	tokenMaker token.Maker
+	config      util.Config
+	db          db.Store
+	router      *gin.Engine
+	tokenMaker  token.Maker
+	revocations *revocationCache
+	currencies  *currencyCache
 }
 
 // NewServer generate a new server
 func NewServer(store db.Store, config util.Config) (*Server, error) {
 
-	tokenMaker, err := token.NewPasetoMaker(config.SemmetricKey)
+	tokenMaker, err := newTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("can't create the tokenmaker: %w", err)
 	}
-	server := &Server{db: store, tokenMaker: tokenMaker}
+	server := &Server{
+		config:      config,
+		db:          store,
+		tokenMaker:  tokenMaker,
+		revocations: newRevocationCache(store, revocationCheckTTL),
+		currencies:  newCurrencyCache(store, currencyCacheTTL),
+	}
 
 	registerCustomValidators()
 
@@ -32,11 +45,51 @@ func NewServer(store db.Store, config util.Config) (*Server, error) {
 	return server, nil
 }
 
+// newTokenMaker create the token maker of TOKEN_TYPE, symmetric makers use the
+// TOKEN_KEYS keyring falling back to SYMMETRIC_KEY, public key makers use
+// the TOKEN_PRIVATE_KEYS and TOKEN_PUBLIC_KEYS pem files
+func newTokenMaker(config util.Config) (token.Maker, error) {
+	switch config.TokenType {
+	case "", "paseto", "jwt":
+		keyring := token.SingleKeyring(config.SemmetricKey)
+		if len(config.TokenKeys) != 0 {
+			var err error
+			keyring, err = token.ParseKeyring(config.TokenActiveKeyID, config.TokenKeys)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if config.TokenType == "jwt" {
+			return token.NewJWTMakerWithKeyring(keyring)
+		}
+		return token.NewPasetoMakerWithKeyring(keyring)
+	case "paseto_public", "jwt_eddsa", "jwt_rs256":
+		keyring, err := token.LoadSigningKeyring(config.TokenActiveKeyID, config.TokenPrivateKeys, config.TokenPublicKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		switch config.TokenType {
+		case "jwt_eddsa":
+			return token.NewJWTPublicMaker(keyring, jwt.SigningMethodEdDSA)
+		case "jwt_rs256":
+			return token.NewJWTPublicMaker(keyring, jwt.SigningMethodRS256)
+		}
+		return token.NewPasetoPublicMaker(keyring)
+	}
+
+	return nil, fmt.Errorf("unsupported token type %q", config.TokenType)
+}
+
 //registerCustomValidators register any custom validator
 func registerCustomValidators() {
 	// register the custom currency validator
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("role", validRole)
+		v.RegisterValidation("exchange_rate", validExchangeRate)
+		v.RegisterTagNameFunc(requestFieldName)
 	}
 }
 
@@ -46,23 +99,81 @@ func (server *Server) SetupRouter() {
 
 	authorized := router.Group("/")
 	{
-		authorized.Use(Authentication(server.tokenMaker))
+		authorized.Use(Authentication(server.tokenMaker, server.revocations))
 		authorized.POST("/accounts", server.createAccount)
 		authorized.GET("/accounts", server.listAccounts)
 		authorized.GET("/accounts/:id", server.getAccount)
+		authorized.POST("/accounts/:id/close", server.closeAccount)
+		authorized.POST("/accounts/:id/deposit", RequireRoles(util.RoleTeller), server.depositMoney)
+		authorized.POST("/accounts/:id/withdraw", RequireRoles(util.RoleTeller), server.withdrawMoney)
 		authorized.POST("/transfers", server.transferAmount)
+		authorized.GET("/transfers", server.listTransfers)
+		authorized.POST("/transfers/batch", server.batchTransfer)
+		authorized.POST("/transfers/quote", server.quoteTransfer)
+		authorized.GET("/transfers/:id", server.getTransfer)
+		authorized.POST("/transfers/:id/reverse", RequireRoles(util.RoleAdmin, util.RoleTeller), server.reverseTransfer)
+		authorized.POST("/holds", server.authorizeHold)
+		authorized.GET("/holds/:id", server.getHold)
+		authorized.POST("/holds/:id/capture", server.captureHold)
+		authorized.POST("/holds/:id/void", server.voidHold)
+		authorized.POST("/scheduled_transfers", server.createScheduledTransfer)
+		authorized.GET("/scheduled_transfers", server.listScheduledTransfers)
+		authorized.GET("/scheduled_transfers/:id", server.getScheduledTransfer)
+		authorized.PATCH("/scheduled_transfers/:id", server.updateScheduledTransfer)
+		authorized.DELETE("/scheduled_transfers/:id", server.cancelScheduledTransfer)
+		authorized.GET("/scheduled_transfers/:id/runs", server.listScheduledTransferRuns)
+		authorized.POST("/users/logout", server.logoutUser)
+		authorized.PUT("/users/password", server.changePassword)
+		authorized.GET("/users/transfer_limits", server.listTransferLimits)
+	}
+
+	backOffice := router.Group("/admin", Authentication(server.tokenMaker, server.revocations))
+	{
+		staff := backOffice.Group("", RequireRoles(util.RoleAdmin, util.RoleTeller, util.RoleAuditor))
+		staff.GET("/accounts", server.listAllAccounts)
+
+		auditors := backOffice.Group("", RequireRoles(util.RoleAdmin, util.RoleAuditor))
+		auditors.GET("/ledger/reconciliation", server.reconcileLedger)
+
+		admins := backOffice.Group("", RequireRoles(util.RoleAdmin))
+		admins.POST("/users/:username/revoke_sessions", server.revokeUserSessions)
+		admins.PUT("/users/:username/role", server.updateUserRole)
+		admins.PUT("/accounts/:id/status", server.updateAccountStatus)
+		admins.PUT("/accounts/:id/overdraft_limit", server.updateOverdraftLimit)
+		admins.POST("/exchange_rates", server.createExchangeRates)
+		admins.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 	}
 
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+	router.GET("/.well-known/jwks.json", server.getJWKS)
+	router.GET("/currencies", server.listCurrencies)
 
 	server.router = router
 }
 
 func (server *Server) Start(address string) {
-	server.router.Run(address)
-}
+	if server.config.BootstrapAdmin != "" {
+		if err := server.bootstrapAdmin(context.Background()); err != nil {
+			log.Println("admin bootstrap failed:", err)
+		}
+	}
+	if server.config.ReconciliationInterval > 0 {
+		go server.runReconciliation(context.Background(), server.config.ReconciliationInterval)
+	}
+	if server.config.HoldExpiryInterval > 0 {
+		go server.runHoldExpiry(context.Background(), server.config.HoldExpiryInterval)
+	}
+	if server.config.ScheduledTransferInterval > 0 {
+		go server.runScheduledTransfers(context.Background(), server.config.ScheduledTransferInterval)
+	}
+	if server.config.PostingSweepInterval > 0 {
+		go server.runPostingSweep(context.Background(), server.config.PostingSweepInterval)
+	}
+	if server.config.RevokedTokenSweepInterval > 0 {
+		go server.runRevokedTokenSweep(context.Background(), server.config.RevokedTokenSweepInterval)
+	}
 
-func responseError(err error) gin.H {
-	return gin.H{"error": err.Error()}
+	server.router.Run(address)
 }
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hamdysherif/simplebank/token"
+)
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	TokenType            string    `json:"type"`
+}
+
+// renewAccessToken issue a new access token for a valid, unblocked refresh token session
+// with the current role of the user
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req renewAccessTokenRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if refreshPayload.Kind != token.RefreshToken {
+		writeError(ctx, errInvalidToken)
+		return
+	}
+
+	revoked, err := server.revocations.isRevoked(ctx, refreshPayload)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if revoked {
+		writeError(ctx, errTokenRevoked)
+		return
+	}
+
+	session, err := server.db.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "session not found"))
+			return
+		}
+		writeError(ctx, err)
+		return
+	}
+
+	if session.IsBlocked {
+		writeError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "blocked session"))
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		writeError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "incorrect session user"))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		writeError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "mismatched session token"))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		writeError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "expired session"))
+		return
+	}
+
+	// the role may have changed since login
+	user, err := server.db.GetUserByUsername(ctx, refreshPayload.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, server.config.TokenDuration)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	res := renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpireAt,
+		TokenType:            "Bearer",
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// getJWKS publish the public keys tokens can be verified with, it is only
+// available when the server signs tokens with a public key maker
+func (server *Server) getJWKS(ctx *gin.Context) {
+	maker, ok := server.tokenMaker.(token.PublicKeyMaker)
+	if !ok {
+		writeError(ctx, newAPIError(http.StatusNotFound, codeNotFound, "tokens are not signed with public keys"))
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, token.NewJWKSet(maker))
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := NewTestServer(t, store)
+
+	username := util.RandomOwner()
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(username, util.RoleCustomer, token.RefreshToken, time.Hour)
+	require.NoError(t, err)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(username, util.RoleCustomer, token.AccessToken, time.Hour)
+	require.NoError(t, err)
+
+	session := db.Session{
+		ID:           refreshPayload.ID,
+		Username:     username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    refreshPayload.ExpireAt,
+	}
+
+	testCases := []struct {
+		name          string
+		params        gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			params: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(refreshPayload.ID)).
+					Times(1).
+					Return(session, nil)
+
+				// the user was made a teller after logging in as a customer
+				store.
+					EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(username)).
+					Times(1).
+					Return(db.User{Username: username, Role: util.RoleTeller}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				require.NoError(t, err)
+				require.NotEmpty(t, res.AccessToken)
+
+				payload, err := server.tokenMaker.VerifyToken(res.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, username, payload.Username)
+				require.Equal(t, util.RoleTeller, payload.Role)
+			},
+		},
+		{
+			name:   "InvalidToken",
+			params: gin.H{"refresh_token": "invalid"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "AccessToken",
+			params: gin.H{"refresh_token": accessToken},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "SessionNotFound",
+			params: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(refreshPayload.ID)).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "BlockedSession",
+			params: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mockdb.MockStore) {
+				blocked := session
+				blocked.IsBlocked = true
+				store.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(refreshPayload.ID)).
+					Times(1).
+					Return(blocked, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "MismatchedToken",
+			params: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mockdb.MockStore) {
+				mismatched := session
+				mismatched.RefreshToken = "another-token"
+				store.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(refreshPayload.ID)).
+					Times(1).
+					Return(mismatched, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalServerError",
+			params: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	url := "/tokens/renew_access"
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+
+			body, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetJWKSAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// symmetric tokens have no public keys to publish
+	server := NewTestServer(t, mockdb.NewMockStore(ctrl))
+
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "k1.pem")
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+
+	config := util.Config{
+		TokenType:        "paseto_public",
+		TokenPrivateKeys: []string{"k1:" + keyPath},
+		TokenActiveKeyID: "k1",
+		TokenDuration:    time.Minute,
+	}
+	server, err = NewServer(mockdb.NewMockStore(ctrl), config)
+	require.NoError(t, err)
+
+	request, err = http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var set token.JWKSet
+	err = json.Unmarshal(recorder.Body.Bytes(), &set)
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 1)
+	require.Equal(t, "k1", set.Keys[0].KeyID)
+	require.Equal(t, base64.RawURLEncoding.EncodeToString(privateKey.Public().(ed25519.PublicKey)), set.Keys[0].X)
+}
@@ -1,53 +1,399 @@
 package api
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
 )
 
+// clients send an Idempotency-Key to retry POST /transfers without transferring twice
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotencyKeyMaxLength = 255
+)
+
+// metadataMaxSize is the largest metadata in bytes a client can attach to a transfer
+const metadataMaxSize = 4096
+
 type transferRequest struct {
 	FromAccount int64  `json:"from_account_id" binding:"required,min=1"`
 	ToAccount   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Amount      string `json:"amount" binding:"required"`
 	Currency    string `json:"currency" binding:"required,currency"`
+	Description string `json:"description" binding:"max=255"`
+	Reference   string `json:"reference" binding:"max=64"`
+	// Metadata is stored as is, the bank doesn't read it
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// parseMetadata check metadata is a json object of at most metadataMaxSize bytes,
+// a missing or null metadata is empty
+func parseMetadata(field string, metadata json.RawMessage) (json.RawMessage, error) {
+	if len(metadata) == 0 || bytes.Equal(metadata, []byte("null")) {
+		return nil, nil
+	}
+	if len(metadata) > metadataMaxSize {
+		return nil, invalidField(field, fmt.Sprintf("must be at most %d bytes", metadataMaxSize))
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(metadata, &object); err != nil {
+		return nil, invalidField(field, "must be a json object")
+	}
+	return metadata, nil
 }
 
 func (server *Server) transferAmount(ctx *gin.Context) {
 	var req transferRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, responseError(err))
+		writeError(ctx, bindingError(err))
 		return
 	}
 
-	if !isValidAccount(server, ctx, req.FromAccount, req.Currency) {
+	if _, err := server.checkCurrency(ctx, "currency", req.Currency); err != nil {
+		writeError(ctx, err)
 		return
 	}
-	if !isValidAccount(server, ctx, req.ToAccount, req.Currency) {
+
+	amount, err := server.parseAmount(ctx, "amount", req.Amount, req.Currency)
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
+	// "12.5" and "12.50" are the same request
+	req.Amount = amount.Decimal()
 
-	result, err := server.db.TransferTx(ctx, db.TransferParams{FromAccountID: req.FromAccount, ToAccountID: req.ToAccount, Amount: req.Amount})
+	req.Metadata, err = parseMetadata("metadata", req.Metadata)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, responseError(err))
+		writeError(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	var requestHash string
+	if key != "" {
+		if len(key) > idempotencyKeyMaxLength {
+			writeError(ctx, newAPIError(http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, idempotencyKeyMaxLength)))
+			return
+		}
+
+		requestHash, err = hashTransferRequest(req)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+
+		if server.replayIdempotentTransfer(ctx, authPayload.Username, key, requestHash) {
+			return
+		}
+	}
+
+	fromAccount, valid := isValidAccount(server, ctx, req.FromAccount, req.Currency)
+	if !valid {
+		return
+	}
+
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, errAccountNotOwned)
+		return
+	}
+
+	// the to account may be in another currency, the store converts the amount
+	if _, valid := getRequestAccount(server, ctx, req.ToAccount); !valid {
+		return
+	}
+
+	arg := db.TransferParams{
+		FromAccountID: req.FromAccount,
+		ToAccountID:   req.ToAccount,
+		Amount:        amount.MinorUnits,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		Metadata:      req.Metadata,
+	}
+
+	if key == "" {
+		result, err := server.db.TransferTx(ctx, arg)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+
+		server.writeTransferResult(ctx, result)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	result, err := server.db.IdempotentTransferTx(ctx, db.IdempotentTransferParams{
+		TransferParams: arg,
+		Username:       authPayload.Username,
+		Key:            key,
+		RequestHash:    requestHash,
+	})
+	if err == db.ErrIdempotencyKeyExists {
+		// a concurrent request with the same key won the race, answer with its result
+		server.replayIdempotentTransfer(ctx, authPayload.Username, key, requestHash)
+		return
+	}
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	server.writeTransferResult(ctx, result)
 }
 
-func isValidAccount(server *Server, ctx *gin.Context, accountID int64, currency string) bool {
-	account, err := server.db.GetAccount(ctx, accountID)
+// writeTransferResult answer with result rendered in the currencies of its accounts,
+// showing the authenticated user only the accounts they can read
+func (server *Server) writeTransferResult(ctx *gin.Context, result db.TransferResult) {
+	p, err := server.presenter(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, responseError(err))
-		return false
+		writeError(ctx, err)
+		return
 	}
-	if account.Currency != currency {
-		ctx.JSON(http.StatusBadRequest, responseError(fmt.Errorf("account [%v], currency not match %v vs %v", account.ID, account.Currency, currency)))
-		return false
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	ctx.JSON(http.StatusOK, p.transferResult(result, authPayload))
+}
+
+// replayIdempotentTransfer answer with the stored response of key and report whether
+// a response was written, it return false when the key has not been used yet
+func (server *Server) replayIdempotentTransfer(ctx *gin.Context, username string, key string, requestHash string) bool {
+	stored, err := server.db.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false
+		}
+		writeError(ctx, err)
+		return true
+	}
+
+	if stored.RequestHash != requestHash {
+		writeError(ctx, errIdempotencyKeyReused)
+		return true
+	}
+
+	var result db.TransferResult
+	if err := json.Unmarshal(stored.Response, &result); err != nil {
+		writeError(ctx, err)
+		return true
 	}
+
+	server.writeTransferResult(ctx, result)
 	return true
 }
+
+// hashTransferRequest return the hex sha256 of the canonical json of req
+func hashTransferRequest(req transferRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func isValidAccount(server *Server, ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, valid := getRequestAccount(server, ctx, accountID)
+	if !valid {
+		return account, false
+	}
+	if account.Currency != currency {
+		writeError(ctx, fmt.Errorf("%w: account [%v] is in %v not %v", db.ErrCurrencyMismatch, account.ID, account.Currency, currency))
+		return account, false
+	}
+	return account, true
+}
+
+// getRequestAccount return the account with accountID, answering with an error when
+// it can't be read
+func getRequestAccount(server *Server, ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.db.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// the account is referenced by the request body, not the url
+			writeError(ctx, newAPIError(http.StatusBadRequest, codeAccountNotFound, fmt.Sprintf("account [%v] not found", accountID)))
+			return account, false
+		}
+		writeError(ctx, err)
+		return account, false
+	}
+	return account, true
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransfer return a transfer with its journal entries to whoever can read either
+// of its accounts
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	journal, err := db.GetTransferJournal(ctx, server.db, req.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	accounts := make(map[int64]db.Account)
+	involved := false
+	for _, accountID := range []int64{journal.Transfer.FromAccountID, journal.Transfer.ToAccountID} {
+		account, err := server.db.GetAccount(ctx, accountID)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		accounts[accountID] = account
+		involved = involved || canReadAccount(authPayload, account)
+	}
+	if !involved {
+		writeError(ctx, newAPIError(http.StatusForbidden, codeForbidden, "transfer doesn't involve an account of the authenticated user"))
+		return
+	}
+
+	// entries of a conversion are also posted to the fx house accounts
+	for _, entry := range journal.Entries {
+		if _, ok := accounts[entry.AccountID]; ok {
+			continue
+		}
+		account, err := server.db.GetAccount(ctx, entry.AccountID)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		accounts[entry.AccountID] = account
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p.journal(journal, accounts))
+}
+
+type listTransfersRequest struct {
+	Page      int32  `form:"page" binding:"required,min=1"`
+	Size      int32  `form:"size" binding:"required,min=5,max=20"`
+	Reference string `form:"reference" binding:"max=64"`
+}
+
+// listTransfers return the transfers from or to the accounts of the authenticated user,
+// newest first, only those with the reference when one is given
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	transfers, err := server.db.SearchTransfers(ctx, db.SearchTransfersParams{
+		Owner:       authPayload.Username,
+		Reference:   req.Reference,
+		OffsetCount: (req.Page - 1) * req.Size,
+		LimitCount:  req.Size,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	// amounts are rendered in the currencies of their accounts
+	currencies := make(map[int64]string)
+	for _, transfer := range transfers {
+		for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+			if _, ok := currencies[accountID]; ok {
+				continue
+			}
+			account, err := server.db.GetAccount(ctx, accountID)
+			if err != nil {
+				writeError(ctx, err)
+				return
+			}
+			currencies[accountID] = account.Currency
+		}
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	rsp := make([]transferResponse, len(transfers))
+	for i, transfer := range transfers {
+		rsp[i] = p.transfer(transfer, currencies[transfer.FromAccountID], currencies[transfer.ToAccountID])
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type reverseTransferRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+type reverseTransferResponse struct {
+	transferResultResponse
+	Reversal db.TransferReversal `json:"reversal"`
+}
+
+// reverseTransfer move the amount of a mistaken transfer back to the sender, recording
+// the staff member who reversed it and why
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	var req reverseTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.db.ReverseTransferTx(ctx, db.ReverseTransferParams{
+		TransferID: uri.ID,
+		ReversedBy: authPayload.Username,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reverseTransferResponse{
+		transferResultResponse: p.transferResult(result.TransferResult, authPayload),
+		Reversal:               result.Reversal,
+	})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
+	"github.com/hamdysherif/simplebank/util"
+)
+
+type transferAllowanceResponse struct {
+	Currency    string     `json:"currency"`
+	MaxAmount   util.Money `json:"max_amount"`
+	DailyAmount util.Money `json:"daily_amount"`
+	DailyCount  int32      `json:"daily_count"`
+	// SentAmount and SentCount are what was sent today
+	SentAmount      util.Money `json:"sent_amount"`
+	SentCount       int64      `json:"sent_count"`
+	RemainingAmount util.Money `json:"remaining_amount"`
+	RemainingCount  int64      `json:"remaining_count"`
+	ResetsAt        time.Time  `json:"resets_at"`
+}
+
+type transferLimitsResponse struct {
+	Tier   string                      `json:"tier"`
+	Limits []transferAllowanceResponse `json:"limits"`
+}
+
+func (p presenter) transferAllowance(allowance db.TransferAllowance) transferAllowanceResponse {
+	currency := allowance.Limit.Currency
+	return transferAllowanceResponse{
+		Currency:        currency,
+		MaxAmount:       p.money(allowance.Limit.MaxAmount, currency),
+		DailyAmount:     p.money(allowance.Limit.DailyAmount, currency),
+		DailyCount:      allowance.Limit.DailyCount,
+		SentAmount:      p.money(allowance.Usage.TotalAmount, currency),
+		SentCount:       allowance.Usage.TransferCount,
+		RemainingAmount: p.money(allowance.RemainingAmount(), currency),
+		RemainingCount:  allowance.RemainingCount(),
+		ResetsAt:        allowance.ResetsAt,
+	}
+}
+
+// listTransferLimits return the transfer limits of the tier of the authenticated user
+// with what is left of them today, currencies that aren't listed have no limit
+func (server *Server) listTransferLimits(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.db.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(ctx, errInvalidToken)
+			return
+		}
+		writeError(ctx, err)
+		return
+	}
+
+	allowances, err := server.db.TransferAllowances(ctx, user, time.Now())
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	p, err := server.presenter(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	rsp := transferLimitsResponse{
+		Tier:   user.Tier,
+		Limits: make([]transferAllowanceResponse, len(allowances)),
+	}
+	for i, allowance := range allowances {
+		rsp.Limits[i] = p.transferAllowance(allowance)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hamdysherif/simplebank/db/mock"
+	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTransferLimitsAPI(t *testing.T) {
+	user := randomUser("secret")
+	allowance := db.TransferAllowance{
+		Limit: db.TransferLimit{
+			Tier:        db.TierStandard,
+			Currency:    util.USD,
+			MaxAmount:   1000000,
+			DailyAmount: 2500000,
+			DailyCount:  50,
+		},
+		Usage:    db.GetDailyTransferUsageRow{TransferCount: 3, TotalAmount: 2400000},
+		ResetsAt: time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					TransferAllowances(gomock.Any(), gomock.Eq(user), gomock.Any()).
+					Times(1).
+					Return([]db.TransferAllowance{allowance}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferLimitsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TierStandard, rsp.Tier)
+				require.Len(t, rsp.Limits, 1)
+				require.Equal(t, int64(100000), rsp.Limits[0].RemainingAmount.MinorUnits)
+				require.Equal(t, int64(47), rsp.Limits[0].RemainingCount)
+			},
+		},
+		{
+			name: "UserNotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().TransferAllowances(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireErrorCode(t, recorder, codeInvalidToken)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			request := httptest.NewRequest(http.MethodGet, "/users/transfer_limits", nil)
+			addAuthorization(t, request, server.tokenMaker, user.Username, util.RoleCustomer)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
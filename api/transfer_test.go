@@ -9,14 +9,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	mockdb "github.com/hamdysherif/simplebank/db/mock"
 	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
 	"github.com/hamdysherif/simplebank/util"
-	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,28 +26,38 @@ func TestTransferAPI(t *testing.T) {
 	account1 := db.Account{
 		ID:       1,
 		Owner:    util.RandomOwner(),
-		Currency: util.AllowedCurrencies()[0],
+		Currency: util.USD,
 		Balance:  500,
 	}
 	account2 := db.Account{
 		ID:       util.RandomInt(1, 10),
 		Owner:    util.RandomOwner(),
-		Currency: util.AllowedCurrencies()[0],
+		Currency: util.USD,
 		Balance:  300,
 	}
 	entry1 := db.Entry{AccountID: account1.ID, Amount: -5}
 	entry2 := db.Entry{AccountID: account2.ID, Amount: 5}
 	trans := db.Transfer{Amount: 5, FromAccountID: account1.ID, ToAccountID: account2.ID}
+	account3 := db.Account{
+		ID:       util.RandomInt(11, 20),
+		Owner:    util.RandomOwner(),
+		Currency: util.SAR,
+		Balance:  300,
+	}
 
 	testCases := []struct {
 		name          string
 		params        gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:   "Ok",
-			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": 5, "currency": account1.Currency},
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -72,20 +81,74 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				body, err := ioutil.ReadAll(recorder.Body)
 				require.NoError(t, err)
-				var r db.TransferResult
+				var r transferResultResponse
 				err = json.Unmarshal(body, &r)
 				require.NoError(t, err)
 
-				require.Equal(t, r.FromAccount, account1)
-				require.Equal(t, r.FromEntry, entry1)
-				require.Equal(t, r.ToAccount, account2)
-				require.Equal(t, r.ToEntry, entry2)
-				require.Equal(t, r.Transfer, trans)
+				p := testPresenter()
+				require.NotNil(t, r.FromAccount)
+				require.Equal(t, *r.FromAccount, p.account(account1))
+				require.Equal(t, r.FromEntry, p.entry(entry1, account1.Currency))
+				// the sender can't read the account of the recipient
+				require.Nil(t, r.ToAccount)
+				require.Equal(t, r.ToEntry, p.entry(entry2, account2.Currency))
+				require.Equal(t, r.Transfer, p.transfer(trans, account1.Currency, account2.Currency))
+				require.Equal(t, "0.05", r.Transfer.Amount.Decimal())
+			},
+		},
+		{
+			name:   "UnauthorizedUser",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account2.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account2.ID).
+					Times(0)
+
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "NoAuthorization",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
 			name:   "InternalServerError",
-			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": 5, "currency": account1.Currency},
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -109,9 +172,168 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:   "InsufficientFunds",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account2.ID).
+					Times(1).
+					Return(account2, nil)
+
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeInsufficientFunds)
+			},
+		},
+		{
+			name:   "TxConflict",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account2.ID).
+					Times(1).
+					Return(account2, nil)
+
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferResult{}, &db.TxConflictError{Reason: "serialization_failure", Attempts: 5})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, txConflictRetryAfter, recorder.Header().Get("Retry-After"))
+				requireErrorCode(t, recorder, codeTxConflict)
+			},
+		},
+		{
+			name:   "TransferLimitExceeded",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account2.ID).
+					Times(1).
+					Return(account2, nil)
+
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferResult{}, fmt.Errorf("%w: no transfers left today", db.ErrTransferLimitExceeded))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeTransferLimit)
+			},
+		},
+		{
+			name:   "CrossCurrency",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account3.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account3.ID).
+					Times(1).
+					Return(account3, nil)
+
+				converted := db.Transfer{Amount: 5, ToAmount: 18, ExchangeRate: "3.75", FromAccountID: account1.ID, ToAccountID: account3.ID}
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), db.TransferParams{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 5}).
+					Times(1).
+					Return(db.TransferResult{Transfer: converted, FromAccount: account1, ToAccount: account3}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var r transferResultResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &r))
+				require.Equal(t, util.NewMoney(18, account3.Currency, 2), r.Transfer.ToAmount)
+				require.Equal(t, "3.75", r.Transfer.ExchangeRate)
+			},
+		},
+		{
+			name:   "NoExchangeRate",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account3.ID, "amount": "0.05", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account3.ID).
+					Times(1).
+					Return(account3, nil)
+
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferResult{}, fmt.Errorf("%w from %s to %s", db.ErrNoExchangeRate, account1.Currency, account3.Currency))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeNoExchangeRate)
+			},
+		},
 		{
 			name:   "BadRequestAmount",
-			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": 0, "currency": account1.Currency},
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -122,9 +344,98 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "WithDetails",
+			params: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "0.05",
+				"currency":        account1.Currency,
+				"description":     "rent",
+				"reference":       "INV-42",
+				"metadata":        gin.H{"order_id": 42},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+
+				store.
+					EXPECT().
+					GetAccount(gomock.Any(), account2.ID).
+					Times(1).
+					Return(account2, nil)
+
+				detailed := trans
+				detailed.Description = "rent"
+				detailed.Reference = "INV-42"
+				detailed.Metadata = json.RawMessage(`{"order_id":42}`)
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), db.TransferParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        5,
+						Description:   detailed.Description,
+						Reference:     detailed.Reference,
+						Metadata:      detailed.Metadata,
+					}).
+					Times(1).
+					Return(db.TransferResult{FromAccount: account1, ToAccount: account2, FromEntry: entry1, ToEntry: entry2, Transfer: detailed}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var r transferResultResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &r))
+
+				require.Equal(t, "rent", r.Transfer.Description)
+				require.Equal(t, "INV-42", r.Transfer.Reference)
+				require.JSONEq(t, `{"order_id":42}`, string(r.Transfer.Metadata))
+			},
+		},
+		{
+			name:   "BadRequestMetadata",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency, "metadata": []int{42}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "BadRequestReference",
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency, "reference": util.RandomString(65)},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name:   "BadRequestFromAccount",
-			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": 10, "currency": account1.Currency},
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.10", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -143,13 +454,16 @@ func TestTransferAPI(t *testing.T) {
 		},
 		{
 			name:   "BadRequestCurrencyNotMatch",
-			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": 10, "currency": account1.Currency},
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.10", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
 					GetAccount(gomock.Any(), account1.ID).
 					Times(1).
-					Return(db.Account{ID: util.RandomInt(5, 100), Owner: util.RandomOwner(), Currency: util.AllowedCurrencies()[1], Balance: 0}, nil)
+					Return(db.Account{ID: util.RandomInt(5, 100), Owner: util.RandomOwner(), Currency: util.SAR, Balance: 0}, nil)
 
 				store.
 					EXPECT().
@@ -158,11 +472,15 @@ func TestTransferAPI(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, codeCurrencyMismatch)
 			},
 		},
 		{
 			name:   "BadRequestToAccount",
-			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": 10, "currency": account1.Currency},
+			params: gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.10", "currency": account1.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, account1.Owner, util.RoleCustomer)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.
 					EXPECT().
@@ -200,15 +518,581 @@ func TestTransferAPI(t *testing.T) {
 
 			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(req))
 
-			authorizationType := "bearer"
-			token, err := server.tokenMaker.CreateToken("hamdy", time.Hour)
+			tc.setupAuth(t, request, server.tokenMaker)
+			recorder := httptest.NewRecorder()
+
+			tc.buildStubs(store)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestTransferIdempotencyAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	account1 := db.Account{ID: 1, Owner: owner, Currency: util.USD, Balance: 500}
+	account2 := db.Account{ID: 2, Owner: util.RandomOwner(), Currency: util.USD, Balance: 300}
+	key := util.RandomString(16)
+
+	params := gin.H{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": "0.05", "currency": account1.Currency}
+	requestHash, err := hashTransferRequest(transferRequest{
+		FromAccount: account1.ID,
+		ToAccount:   account2.ID,
+		Amount:      "0.05",
+		Currency:    account1.Currency,
+	})
+	require.NoError(t, err)
+
+	result := db.TransferResult{
+		FromAccount: account1,
+		ToAccount:   account2,
+		Transfer:    db.Transfer{ID: 7, Amount: 5, FromAccountID: account1.ID, ToAccountID: account2.ID, Metadata: json.RawMessage(`{}`)},
+	}
+	stored := db.IdempotencyKey{Username: owner, Key: key, RequestHash: requestHash}
+	stored.Response, err = json.Marshal(result)
+	require.NoError(t, err)
+	response, err := json.Marshal(testPresenter().transferResult(result, &token.Payload{Username: owner, Role: util.RoleCustomer}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		key           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "NewKey",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(db.GetIdempotencyKeyParams{Username: owner, Key: key})).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+				store.
+					EXPECT().
+					IdempotentTransferTx(gomock.Any(), gomock.Eq(db.IdempotentTransferParams{
+						TransferParams: db.TransferParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 5},
+						Username:       owner,
+						Key:            key,
+						RequestHash:    requestHash,
+					})).
+					Times(1).
+					Return(result, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, string(response), recorder.Body.String())
+			},
+		},
+		{
+			name: "Replay",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(stored, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, string(response), recorder.Body.String())
+			},
+		},
+		{
+			name: "KeyReusedWithDifferentRequest",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				other := stored
+				other.RequestHash = "another-hash"
+				store.
+					EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(other, nil)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "ConcurrentRequest",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.
+						EXPECT().
+						GetIdempotencyKey(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.IdempotencyKey{}, sql.ErrNoRows),
+					store.
+						EXPECT().
+						IdempotentTransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferResult{}, db.ErrIdempotencyKeyExists),
+					store.
+						EXPECT().
+						GetIdempotencyKey(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(stored, nil),
+				)
+				store.EXPECT().GetAccount(gomock.Any(), account1.ID).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), account2.ID).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, string(response), recorder.Body.String())
+			},
+		},
+		{
+			name: "KeyTooLong",
+			key:  util.RandomString(idempotencyKeyMaxLength + 1),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			data, err := json.Marshal(params)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			request.Header.Set(idempotencyKeyHeader, tc.key)
+			addAuthorization(t, request, server.tokenMaker, owner, util.RoleCustomer)
 
-			assert.NoError(t, err)
-			request.Header.Set("authorization", fmt.Sprintf("%s %s", authorizationType, token))
 			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferAPI(t *testing.T) {
+	account1 := randomAccount()
+	account2 := randomAccount()
+	account2.ID = util.RandomInt(11, 20)
+	transfer := db.Transfer{ID: util.RandomInt(1, 1000), FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10}
+	journalID := sql.NullInt64{Int64: transfer.ID, Valid: true}
+	entries := []db.Entry{
+		{ID: 1, AccountID: account1.ID, Amount: -10, TransferID: journalID, Type: db.EntryTypeTransfer},
+		{ID: 2, AccountID: account2.ID, Amount: 10, TransferID: journalID, Type: db.EntryTypeTransfer},
+	}
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "SenderOK",
+			transferID: transfer.ID,
+			username:   account1.Owner,
+			role:       util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ListEntriesByTransfer(gomock.Any(), gomock.Eq(journalID)).Times(1).Return(entries, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var journal transferJournalResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &journal))
+
+				p := testPresenter()
+				require.Equal(t, p.transfer(transfer, account1.Currency, account2.Currency), journal.Transfer)
+				require.Equal(t, []entryResponse{
+					p.entry(entries[0], account1.Currency),
+					p.entry(entries[1], account2.Currency),
+				}, journal.Entries)
+			},
+		},
+		{
+			name:       "RecipientOK",
+			transferID: transfer.ID,
+			username:   account2.Owner,
+			role:       util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ListEntriesByTransfer(gomock.Any(), gomock.Eq(journalID)).Times(1).Return(entries, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "Forbidden",
+			transferID: transfer.ID,
+			username:   util.RandomOwner(),
+			role:       util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ListEntriesByTransfer(gomock.Any(), gomock.Any()).Times(1).Return(entries, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "Teller",
+			transferID: transfer.ID,
+			username:   util.RandomOwner(),
+			role:       util.RoleTeller,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ListEntriesByTransfer(gomock.Any(), gomock.Eq(journalID)).Times(1).Return(entries, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "NotFound",
+			transferID: transfer.ID,
+			username:   account1.Owner,
+			role:       util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().ListEntriesByTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:       "BadRequest",
+			transferID: 0,
+			username:   account1.Owner,
+			role:       util.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
+			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/transfers/%d", tc.transferID), nil)
+			addAuthorization(t, request, server.tokenMaker, tc.username, tc.role)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListTransfersAPI(t *testing.T) {
+	account1 := randomAccount()
+	account2 := randomAccount()
+	account2.ID = util.RandomInt(11, 20)
+	account2.Currency = util.EUR
+	transfers := []db.Transfer{
+		{ID: 2, FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 20, ToAmount: 25, Reference: "INV-42", Metadata: json.RawMessage(`{}`)},
+		{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, ToAmount: 8, Metadata: json.RawMessage(`{"order_id":42}`)},
+	}
 
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page=1&size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchTransfers(gomock.Any(), gomock.Eq(db.SearchTransfersParams{Owner: account1.Owner, OffsetCount: 0, LimitCount: 5})).
+					Times(1).
+					Return(transfers, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp []transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+
+				p := testPresenter()
+				require.Equal(t, []transferResponse{
+					p.transfer(transfers[0], account2.Currency, account1.Currency),
+					p.transfer(transfers[1], account1.Currency, account2.Currency),
+				}, rsp)
+			},
+		},
+		{
+			name:  "ByReference",
+			query: "page=2&size=5&reference=INV-42",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchTransfers(gomock.Any(), gomock.Eq(db.SearchTransfersParams{Owner: account1.Owner, Reference: "INV-42", OffsetCount: 5, LimitCount: 5})).
+					Times(1).
+					Return(transfers[:1], nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp []transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp, 1)
+				require.Equal(t, "INV-42", rsp[0].Reference)
+			},
+		},
+		{
+			name:  "Empty",
+			query: "page=1&size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SearchTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.Transfer{}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "page=1&size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SearchTransfers(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:  "BadRequestSize",
+			query: "page=1&size=50",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SearchTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			request := httptest.NewRequest(http.MethodGet, "/transfers?"+tc.query, nil)
+			addAuthorization(t, request, server.tokenMaker, account1.Owner, util.RoleCustomer)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestReverseTransferAPI(t *testing.T) {
+	transferID := util.RandomInt(1, 1000)
+	staff := util.RandomOwner()
+	result := db.ReverseTransferResult{
+		TransferResult: db.TransferResult{
+			Transfer: db.Transfer{ID: transferID + 1, Amount: 10},
+		},
+		Reversal: db.TransferReversal{
+			TransferID:         transferID,
+			ReversalTransferID: transferID + 1,
+			ReversedBy:         staff,
+			Reason:             "duplicate payment",
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		role          string
+		params        gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			role:   util.RoleTeller,
+			params: gin.H{"reason": "duplicate payment"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Eq(db.ReverseTransferParams{
+						TransferID: transferID,
+						ReversedBy: staff,
+						Reason:     "duplicate payment",
+					})).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got reverseTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, result.Transfer.ID, got.Transfer.ID)
+				require.Equal(t, result.Reversal.ReversalTransferID, got.Reversal.ReversalTransferID)
+				require.Equal(t, staff, got.Reversal.ReversedBy)
+				// staff read the accounts of every user
+				require.NotNil(t, got.FromAccount)
+				require.NotNil(t, got.ToAccount)
+			},
+		},
+		{
+			name:   "Customer",
+			role:   util.RoleCustomer,
+			params: gin.H{"reason": "duplicate payment"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "MissingReason",
+			role:   util.RoleAdmin,
+			params: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "AlreadyReversed",
+			role:   util.RoleAdmin,
+			params: gin.H{"reason": "duplicate payment"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReverseTransferResult{}, db.ErrAlreadyReversed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, codeAlreadyReversed)
+			},
+		},
+		{
+			name:   "ReversalOfReversal",
+			role:   util.RoleAdmin,
+			params: gin.H{"reason": "duplicate payment"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReverseTransferResult{}, db.ErrReversalNotReversible)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, codeReversalReversed)
+			},
+		},
+		{
+			name:   "InsufficientFunds",
+			role:   util.RoleAdmin,
+			params: gin.H{"reason": "duplicate payment"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReverseTransferResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, codeInsufficientFunds)
+			},
+		},
+		{
+			name:   "NotFound",
+			role:   util.RoleAdmin,
+			params: gin.H{"reason": "duplicate payment"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.
+					EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReverseTransferResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := NewTestServer(t, store)
+
+			data, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/transfers/%d/reverse", transferID)
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			addAuthorization(t, request, server.tokenMaker, staff, tc.role)
+
+			recorder := httptest.NewRecorder()
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
@@ -1,12 +1,14 @@
 package api
 
 import (
-	"fmt"
+	"database/sql"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/hamdysherif/simplebank/db/sqlc"
+	"github.com/hamdysherif/simplebank/token"
 	"github.com/hamdysherif/simplebank/util"
 )
 
@@ -21,13 +23,13 @@ func (server *Server) createUser(c *gin.Context) {
 	var req createUserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, responseError(err))
+		writeError(c, bindingError(err))
 		return
 	}
 
 	hashedPassword, err := util.GenerateHashedPassowrd(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, responseError(err))
+		writeError(c, err)
 		return
 	}
 
@@ -40,7 +42,7 @@ func (server *Server) createUser(c *gin.Context) {
 
 	user, err := server.db.CreateUser(c, arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, responseError(err))
+		writeError(c, err)
 		return
 	}
 
@@ -52,32 +54,170 @@ type loginUserRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type loginUserResponse struct {
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	TokenType             string    `json:"type"`
+}
+
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, responseError(err))
+		writeError(ctx, bindingError(err))
 		return
 	}
 
 	user, err := server.db.GetUserByUsername(ctx, req.Username)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, responseError(err))
+		if err == sql.ErrNoRows {
+			// unknown users look the same as wrong passwords
+			writeError(ctx, errInvalidCredentials)
+			return
+		}
+		writeError(ctx, err)
 		return
 	}
 
 	if !util.CheckHashedPassword(user.HashedPassword, req.Password) {
-		ctx.JSON(http.StatusForbidden, responseError(fmt.Errorf("invalid username or password")))
+		writeError(ctx, errInvalidCredentials)
 		return
 	}
 
-	token, err := server.tokenMaker.CreateToken(user.Username, time.Hour)
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, server.config.TokenDuration)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
 
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.RefreshToken, server.config.RefreshTokenDuration)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, responseError(err))
+		writeError(ctx, err)
 		return
 	}
 
-	res := gin.H{"token": token, "type": "Bearer"}
+	session, err := server.db.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpireAt,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	res := loginUserResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpireAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpireAt,
+		TokenType:             "Bearer",
+	}
 	ctx.JSON(http.StatusOK, res)
 }
+
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// logoutUser revoke the access token of the request and, when given, the refresh token of its session
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			writeError(ctx, bindingError(err))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if req.RefreshToken != "" {
+		refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		if refreshPayload.Kind != token.RefreshToken {
+			writeError(ctx, invalidField("refresh_token", "must be a refresh token"))
+			return
+		}
+		if refreshPayload.Username != authPayload.Username {
+			writeError(ctx, newAPIError(http.StatusForbidden, codeForbidden, "refresh token belongs to another user"))
+			return
+		}
+
+		if err := server.db.BlockSession(ctx, refreshPayload.ID); err != nil {
+			writeError(ctx, err)
+			return
+		}
+		if err := server.revocations.revoke(ctx, refreshPayload); err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+
+	if err := server.revocations.revoke(ctx, authPayload); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// changePassword replace the password of the authenticated user, every token
+// issued before the change is rejected afterwards including the one used here
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, bindingError(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.db.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if !util.CheckHashedPassword(user.HashedPassword, req.OldPassword) {
+		writeError(ctx, newAPIError(http.StatusForbidden, codeInvalidCredentials, "invalid password"))
+		return
+	}
+
+	hashedPassword, err := util.GenerateHashedPassowrd(req.NewPassword)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	user, err = server.db.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		Username:       user.Username,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	server.revocations.setTokensRevokedAt(user.Username, user.TokensRevokedAt)
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -1,14 +1,58 @@
 package api
 
 import (
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/hamdysherif/simplebank/util"
 )
 
+// currencyCodePattern is the shape of the codes of the currencies table
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// validCurrency accept currency codes, whether the currency is supported is checked
+// by the handlers through server.checkCurrency
 var validCurrency validator.Func = func(field validator.FieldLevel) bool {
-	if currency, ok := field.Field().Interface().(string); ok {
-		return util.ValidCurrency(currency)
+	code, ok := field.Field().Interface().(string)
+	return ok && currencyCodePattern.MatchString(code)
+}
+
+var validRole validator.Func = func(field validator.FieldLevel) bool {
+	if role, ok := field.Field().Interface().(string); ok {
+		return util.ValidRole(role)
 	}
 
 	return false
 }
+
+// exchangeRatePattern accept the plain decimals postgres numeric parses, without
+// signs, exponents or fractions
+var exchangeRatePattern = regexp.MustCompile(`^[0-9]{1,12}(\.[0-9]{1,12})?$`)
+
+var validExchangeRate validator.Func = func(field validator.FieldLevel) bool {
+	rate, ok := field.Field().Interface().(string)
+	if !ok || !exchangeRatePattern.MatchString(rate) {
+		return false
+	}
+
+	r, ok := new(big.Rat).SetString(rate)
+	return ok && r.Sign() > 0
+}
+
+// requestFieldName name validation errors after the json, uri or form key of the
+// field so error details match what the client sent
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "uri", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
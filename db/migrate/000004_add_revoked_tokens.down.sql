@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "sessions_username_idx";
+DROP TABLE IF EXISTS "revoked_tokens";
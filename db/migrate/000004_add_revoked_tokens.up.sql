@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "revoked_tokens" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE IF EXISTS "revoked_tokens" ADD CONSTRAINT "fk_revoked_tokens_users" FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "revoked_tokens" ("expires_at");
+
+CREATE INDEX ON "sessions" ("username");
+
+COMMENT ON COLUMN "revoked_tokens"."id" IS 'the payload id of the revoked access or refresh token';
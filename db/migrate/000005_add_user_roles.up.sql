@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'customer';
+ALTER TABLE IF EXISTS "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('customer', 'teller', 'admin', 'auditor'));
+
+COMMENT ON COLUMN "users"."role" IS 'customer, teller, admin or auditor';
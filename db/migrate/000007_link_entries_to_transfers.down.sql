@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "entries_transfer_id_idx";
+ALTER TABLE IF EXISTS "entries" DROP CONSTRAINT IF EXISTS "entries_type_check";
+ALTER TABLE IF EXISTS "entries" DROP CONSTRAINT IF EXISTS "fk_entries_transfers";
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "type";
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "transfer_id";
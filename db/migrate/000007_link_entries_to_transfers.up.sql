@@ -0,0 +1,22 @@
+ALTER TABLE IF EXISTS "entries" ADD COLUMN "transfer_id" bigint;
+ALTER TABLE IF EXISTS "entries" ADD COLUMN "type" varchar;
+
+ALTER TABLE IF EXISTS "entries" ADD CONSTRAINT "fk_entries_transfers" FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+-- entries written by TransferTx share the transaction timestamp of their transfer
+UPDATE "entries" e SET "transfer_id" = t."id", "type" = 'transfer'
+FROM "transfers" t
+WHERE e."created_at" = t."created_at"
+  AND ((e."account_id" = t."from_account_id" AND e."amount" = -t."amount")
+    OR (e."account_id" = t."to_account_id" AND e."amount" = t."amount"));
+
+UPDATE "entries" SET "type" = CASE WHEN "amount" >= 0 THEN 'deposit' ELSE 'withdrawal' END
+WHERE "type" IS NULL;
+
+ALTER TABLE IF EXISTS "entries" ALTER COLUMN "type" SET NOT NULL;
+ALTER TABLE IF EXISTS "entries" ADD CONSTRAINT "entries_type_check" CHECK ("type" IN ('transfer', 'deposit', 'withdrawal', 'fee', 'interest', 'reversal'));
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer journal the entry belongs to, null for entries outside a transfer';
+COMMENT ON COLUMN "entries"."type" IS 'transfer, deposit, withdrawal, fee, interest or reversal';
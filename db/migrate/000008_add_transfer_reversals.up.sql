@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "transfer_reversals" (
+  "transfer_id" bigint PRIMARY KEY,
+  "reversal_transfer_id" bigint UNIQUE NOT NULL,
+  "reversed_by" varchar NOT NULL,
+  "reason" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE IF EXISTS "transfer_reversals" ADD CONSTRAINT "fk_transfer_reversals_transfers" FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+ALTER TABLE IF EXISTS "transfer_reversals" ADD CONSTRAINT "fk_transfer_reversals_reversal_transfers" FOREIGN KEY ("reversal_transfer_id") REFERENCES "transfers" ("id");
+ALTER TABLE IF EXISTS "transfer_reversals" ADD CONSTRAINT "fk_transfer_reversals_users" FOREIGN KEY ("reversed_by") REFERENCES "users" ("username");
+
+COMMENT ON COLUMN "transfer_reversals"."transfer_id" IS 'the reversed transfer, a transfer can be reversed once';
+COMMENT ON COLUMN "transfer_reversals"."reversal_transfer_id" IS 'the compensating transfer moving the amount back';
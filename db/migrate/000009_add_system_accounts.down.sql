@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS "system_accounts";
+
+DELETE FROM "accounts" a
+USING "users" u
+WHERE u."id" = a."user_id" AND u."username" = 'system'
+  AND NOT EXISTS (SELECT 1 FROM "entries" e WHERE e."account_id" = a."id");
+
+DELETE FROM "users" u
+WHERE u."username" = 'system'
+  AND NOT EXISTS (SELECT 1 FROM "accounts" a WHERE a."user_id" = u."id");
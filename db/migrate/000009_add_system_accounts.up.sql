@@ -0,0 +1,34 @@
+-- the bank's own accounts are owned by a system user that can't log in, a customer
+-- already registered as system would own them, so the migration stops instead
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM "users" WHERE "username" = 'system') THEN
+    RAISE EXCEPTION 'username system is reserved for the bank, rename that user before migrating';
+  END IF;
+END $$;
+
+INSERT INTO "users" ("username", "full_name", "email", "hashed_password")
+VALUES ('system', 'Simple Bank', 'system@simplebank.local', '!');
+
+CREATE TABLE IF NOT EXISTS "system_accounts" (
+  "purpose" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "account_id" bigint UNIQUE NOT NULL,
+  PRIMARY KEY ("purpose", "currency")
+);
+
+ALTER TABLE IF EXISTS "system_accounts" ADD CONSTRAINT "fk_system_accounts_accounts" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "user_id")
+SELECT u."username", 0, c."currency", u."id"
+FROM "users" u, (VALUES ('USD'), ('SAR'), ('LE'), ('EUR')) AS c("currency")
+WHERE u."username" = 'system'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO "system_accounts" ("purpose", "currency", "account_id")
+SELECT 'cash', a."currency", a."id"
+FROM "accounts" a
+JOIN "users" u ON u."id" = a."user_id"
+WHERE u."username" = 'system';
+
+COMMENT ON COLUMN "system_accounts"."purpose" IS 'cash is the counterpart of deposits and withdrawals';
@@ -0,0 +1,16 @@
+DELETE FROM "system_accounts" WHERE "purpose" = 'fx';
+
+DELETE FROM "accounts" a
+USING "users" u
+WHERE u."id" = a."user_id" AND u."username" = 'system'
+  AND NOT EXISTS (SELECT 1 FROM "system_accounts" s WHERE s."account_id" = a."id")
+  AND NOT EXISTS (SELECT 1 FROM "entries" e WHERE e."account_id" = a."id");
+
+DROP INDEX IF EXISTS "account_currency_unique";
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "account_currency_unique" UNIQUE ("user_id", "currency");
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "exchange_rate";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_amount";
+
+DROP TABLE IF EXISTS "exchange_rates";
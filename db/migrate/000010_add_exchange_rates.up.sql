@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS "exchange_rates" (
+  "id" bigserial PRIMARY KEY,
+  "base_currency" varchar NOT NULL,
+  "quote_currency" varchar NOT NULL,
+  "rate" numeric NOT NULL,
+  "valid_from" timestamptz NOT NULL DEFAULT (now()),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE IF EXISTS "exchange_rates" ADD CONSTRAINT "exchange_rate_valid_from_unique" UNIQUE ("base_currency", "quote_currency", "valid_from");
+ALTER TABLE IF EXISTS "exchange_rates" ADD CONSTRAINT "exchange_rates_rate_check" CHECK ("rate" > 0);
+ALTER TABLE IF EXISTS "exchange_rates" ADD CONSTRAINT "exchange_rates_pair_check" CHECK ("base_currency" <> "quote_currency");
+
+COMMENT ON COLUMN "exchange_rates"."rate" IS 'the price of one unit of base_currency in quote_currency';
+COMMENT ON COLUMN "exchange_rates"."valid_from" IS 'the rate is in effect until a later valid_from of the same pair';
+
+ALTER TABLE IF EXISTS "transfers" ADD COLUMN "to_amount" bigint;
+ALTER TABLE IF EXISTS "transfers" ADD COLUMN "exchange_rate" numeric NOT NULL DEFAULT 1;
+UPDATE "transfers" SET "to_amount" = "amount";
+ALTER TABLE IF EXISTS "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive, in the currency of from_account_id';
+COMMENT ON COLUMN "transfers"."to_amount" IS 'the amount credited in the currency of to_account_id';
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'the rate amount was converted with, 1 between accounts of the same currency';
+
+-- the fx house accounts hold a second system account per currency
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "account_currency_unique";
+CREATE UNIQUE INDEX IF NOT EXISTS "account_currency_unique" ON "accounts" ("user_id", "currency") WHERE "owner" <> 'system';
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "user_id")
+SELECT u."username", 0, c."currency", u."id"
+FROM "users" u, (VALUES ('USD'), ('SAR'), ('LE'), ('EUR')) AS c("currency")
+WHERE u."username" = 'system';
+
+INSERT INTO "system_accounts" ("purpose", "currency", "account_id")
+SELECT DISTINCT ON (a."currency") 'fx', a."currency", a."id"
+FROM "accounts" a
+JOIN "users" u ON u."id" = a."user_id"
+WHERE u."username" = 'system'
+  AND NOT EXISTS (SELECT 1 FROM "system_accounts" s WHERE s."account_id" = a."id")
+ORDER BY a."currency", a."id";
+
+COMMENT ON COLUMN "system_accounts"."purpose" IS 'cash is the counterpart of deposits and withdrawals, fx of currency conversions';
@@ -0,0 +1,11 @@
+ALTER TABLE IF EXISTS "exchange_rates" DROP CONSTRAINT IF EXISTS "fk_exchange_rates_quote_currencies";
+ALTER TABLE IF EXISTS "exchange_rates" DROP CONSTRAINT IF EXISTS "fk_exchange_rates_base_currencies";
+ALTER TABLE IF EXISTS "system_accounts" DROP CONSTRAINT IF EXISTS "fk_system_accounts_currencies";
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "fk_accounts_currencies";
+
+UPDATE "accounts" SET "currency" = 'LE' WHERE "currency" = 'EGP';
+UPDATE "system_accounts" SET "currency" = 'LE' WHERE "currency" = 'EGP';
+UPDATE "exchange_rates" SET "base_currency" = 'LE' WHERE "base_currency" = 'EGP';
+UPDATE "exchange_rates" SET "quote_currency" = 'LE' WHERE "quote_currency" = 'EGP';
+
+DROP TABLE IF EXISTS "currencies";
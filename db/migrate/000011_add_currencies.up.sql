@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS "currencies" (
+  "code" varchar PRIMARY KEY,
+  "exponent" integer NOT NULL,
+  "enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE IF EXISTS "currencies" ADD CONSTRAINT "currencies_code_check" CHECK ("code" ~ '^[A-Z]{3}$');
+ALTER TABLE IF EXISTS "currencies" ADD CONSTRAINT "currencies_exponent_check" CHECK ("exponent" BETWEEN 0 AND 4);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 alphabetic code';
+COMMENT ON COLUMN "currencies"."exponent" IS 'ISO 4217 minor unit, amounts in this currency are in 10^-exponent of a unit';
+COMMENT ON COLUMN "currencies"."enabled" IS 'new accounts can only be opened in enabled currencies';
+
+INSERT INTO "currencies" ("code", "exponent") VALUES
+  ('USD', 2),
+  ('SAR', 2),
+  ('EGP', 2),
+  ('EUR', 2);
+
+-- LE was the local name of the egyptian pound
+UPDATE "accounts" SET "currency" = 'EGP' WHERE "currency" = 'LE';
+UPDATE "system_accounts" SET "currency" = 'EGP' WHERE "currency" = 'LE';
+UPDATE "exchange_rates" SET "base_currency" = 'EGP' WHERE "base_currency" = 'LE';
+UPDATE "exchange_rates" SET "quote_currency" = 'EGP' WHERE "quote_currency" = 'LE';
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "fk_accounts_currencies" FOREIGN KEY ("currency") REFERENCES "currencies" ("code") ON UPDATE CASCADE;
+ALTER TABLE IF EXISTS "system_accounts" ADD CONSTRAINT "fk_system_accounts_currencies" FOREIGN KEY ("currency") REFERENCES "currencies" ("code") ON UPDATE CASCADE;
+ALTER TABLE IF EXISTS "exchange_rates" ADD CONSTRAINT "fk_exchange_rates_base_currencies" FOREIGN KEY ("base_currency") REFERENCES "currencies" ("code") ON UPDATE CASCADE;
+ALTER TABLE IF EXISTS "exchange_rates" ADD CONSTRAINT "fk_exchange_rates_quote_currencies" FOREIGN KEY ("quote_currency") REFERENCES "currencies" ("code") ON UPDATE CASCADE;
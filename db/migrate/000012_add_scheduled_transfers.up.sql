@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "frequency" varchar NOT NULL,
+  "starts_at" timestamptz NOT NULL,
+  "ends_at" timestamptz,
+  "next_run_at" timestamptz NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE IF EXISTS "scheduled_transfers" ADD CONSTRAINT "fk_scheduled_transfers_users" FOREIGN KEY ("owner") REFERENCES "users" ("username");
+ALTER TABLE IF EXISTS "scheduled_transfers" ADD CONSTRAINT "fk_scheduled_transfers_from_accounts" FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE IF EXISTS "scheduled_transfers" ADD CONSTRAINT "fk_scheduled_transfers_to_accounts" FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE IF EXISTS "scheduled_transfers" ADD CONSTRAINT "fk_scheduled_transfers_currencies" FOREIGN KEY ("currency") REFERENCES "currencies" ("code") ON UPDATE CASCADE;
+ALTER TABLE IF EXISTS "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_amount_check" CHECK ("amount" > 0);
+ALTER TABLE IF EXISTS "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_frequency_check" CHECK ("frequency" IN ('once', 'daily', 'weekly', 'monthly'));
+ALTER TABLE IF EXISTS "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_status_check" CHECK ("status" IN ('active', 'paused', 'completed', 'cancelled'));
+
+CREATE INDEX IF NOT EXISTS "scheduled_transfers_owner_idx" ON "scheduled_transfers" ("owner");
+-- the scheduler only ever looks for due active schedules
+CREATE INDEX IF NOT EXISTS "scheduled_transfers_due_idx" ON "scheduled_transfers" ("next_run_at") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'in currency, the currency of from_account_id';
+COMMENT ON COLUMN "scheduled_transfers"."frequency" IS 'once, daily, weekly or monthly counted from starts_at';
+COMMENT ON COLUMN "scheduled_transfers"."ends_at" IS 'no run is scheduled after it, null repeats until cancelled';
+COMMENT ON COLUMN "scheduled_transfers"."status" IS 'active, paused, completed or cancelled, only active schedules run';
+
+CREATE TABLE IF NOT EXISTS "scheduled_transfer_runs" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "scheduled_for" timestamptz NOT NULL,
+  "status" varchar NOT NULL,
+  "transfer_id" bigint,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE IF EXISTS "scheduled_transfer_runs" ADD CONSTRAINT "fk_scheduled_transfer_runs_scheduled_transfers" FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+ALTER TABLE IF EXISTS "scheduled_transfer_runs" ADD CONSTRAINT "fk_scheduled_transfer_runs_transfers" FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+ALTER TABLE IF EXISTS "scheduled_transfer_runs" ADD CONSTRAINT "scheduled_transfer_runs_status_check" CHECK ("status" IN ('succeeded', 'failed'));
+
+CREATE INDEX IF NOT EXISTS "scheduled_transfer_runs_scheduled_transfer_id_idx" ON "scheduled_transfer_runs" ("scheduled_transfer_id");
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."scheduled_for" IS 'the next_run_at the run was due at';
+COMMENT ON COLUMN "scheduled_transfer_runs"."transfer_id" IS 'the transfer made by a succeeded run';
+COMMENT ON COLUMN "scheduled_transfer_runs"."error" IS 'why a failed run was rejected';
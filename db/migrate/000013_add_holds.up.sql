@@ -0,0 +1,35 @@
+ALTER TABLE IF EXISTS "accounts" ADD COLUMN "hold_amount" bigint NOT NULL DEFAULT 0;
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "accounts_hold_amount_check" CHECK ("hold_amount" >= 0);
+
+COMMENT ON COLUMN "accounts"."hold_amount" IS 'the total of the pending holds on the account, the available balance is balance - hold_amount';
+
+CREATE TABLE IF NOT EXISTS "holds" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE IF EXISTS "holds" ADD CONSTRAINT "fk_holds_from_accounts" FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE IF EXISTS "holds" ADD CONSTRAINT "fk_holds_to_accounts" FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE IF EXISTS "holds" ADD CONSTRAINT "fk_holds_currencies" FOREIGN KEY ("currency") REFERENCES "currencies" ("code") ON UPDATE CASCADE;
+ALTER TABLE IF EXISTS "holds" ADD CONSTRAINT "fk_holds_transfers" FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+ALTER TABLE IF EXISTS "holds" ADD CONSTRAINT "holds_amount_check" CHECK ("amount" > 0);
+ALTER TABLE IF EXISTS "holds" ADD CONSTRAINT "holds_captured_amount_check" CHECK ("captured_amount" >= 0 AND "captured_amount" <= "amount");
+ALTER TABLE IF EXISTS "holds" ADD CONSTRAINT "holds_status_check" CHECK ("status" IN ('pending', 'captured', 'voided', 'expired'));
+
+CREATE INDEX IF NOT EXISTS "holds_from_account_id_idx" ON "holds" ("from_account_id");
+-- the expiry sweep only ever looks for pending holds
+CREATE INDEX IF NOT EXISTS "holds_expires_at_idx" ON "holds" ("expires_at") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "holds"."amount" IS 'the authorized amount in currency, the currency of from_account_id';
+COMMENT ON COLUMN "holds"."captured_amount" IS 'the part of amount settled by the capture, the rest was released';
+COMMENT ON COLUMN "holds"."status" IS 'pending, captured, voided or expired, only pending holds reduce the available balance';
+COMMENT ON COLUMN "holds"."transfer_id" IS 'the transfer made by the capture';
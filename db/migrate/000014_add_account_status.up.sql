@@ -0,0 +1,10 @@
+ALTER TABLE IF EXISTS "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+ALTER TABLE IF EXISTS "accounts" ADD COLUMN "status_reason" varchar NOT NULL DEFAULT '';
+ALTER TABLE IF EXISTS "accounts" ADD COLUMN "status_changed_at" timestamptz NOT NULL DEFAULT (now());
+ALTER TABLE IF EXISTS "accounts" ADD COLUMN "closed_at" timestamptz;
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "accounts_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed, only active accounts send and receive money';
+COMMENT ON COLUMN "accounts"."status_reason" IS 'why the account was last frozen, unfrozen or closed';
+COMMENT ON COLUMN "accounts"."status_changed_at" IS 'when the status was last changed';
+COMMENT ON COLUMN "accounts"."closed_at" IS 'when the account was closed, closing is final';
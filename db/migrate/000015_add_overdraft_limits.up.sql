@@ -0,0 +1,11 @@
+ALTER TABLE IF EXISTS "accounts" ADD COLUMN "overdraft_limit" bigint DEFAULT 0;
+
+-- the bank's own accounts take the other side of deposits and conversions and have no limit
+UPDATE "accounts" SET "overdraft_limit" = NULL
+WHERE "id" IN (SELECT "account_id" FROM "system_accounts");
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "accounts_overdraft_limit_check" CHECK ("overdraft_limit" >= 0);
+-- enforced by postgres so concurrent transfers can't overdraw an account past its limit
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "accounts_balance_check" CHECK ("overdraft_limit" IS NULL OR "balance" >= -"overdraft_limit");
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how far below zero the balance can go, null for the bank''s own accounts which have no limit';
@@ -0,0 +1,33 @@
+ALTER TABLE IF EXISTS "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+ALTER TABLE IF EXISTS "users" ADD CONSTRAINT "users_tier_check" CHECK ("tier" IN ('standard', 'premium'));
+
+COMMENT ON COLUMN "users"."tier" IS 'standard or premium, picks the transfer limits of the user';
+
+CREATE TABLE IF NOT EXISTS "transfer_limits" (
+  "tier" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "max_amount" bigint NOT NULL,
+  "daily_amount" bigint NOT NULL,
+  "daily_count" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("tier", "currency")
+);
+
+ALTER TABLE IF EXISTS "transfer_limits" ADD CONSTRAINT "fk_transfer_limits_currencies" FOREIGN KEY ("currency") REFERENCES "currencies" ("code") ON UPDATE CASCADE;
+ALTER TABLE IF EXISTS "transfer_limits" ADD CONSTRAINT "transfer_limits_tier_check" CHECK ("tier" IN ('standard', 'premium'));
+ALTER TABLE IF EXISTS "transfer_limits" ADD CONSTRAINT "transfer_limits_max_amount_check" CHECK ("max_amount" > 0);
+ALTER TABLE IF EXISTS "transfer_limits" ADD CONSTRAINT "transfer_limits_daily_amount_check" CHECK ("daily_amount" >= "max_amount");
+ALTER TABLE IF EXISTS "transfer_limits" ADD CONSTRAINT "transfer_limits_daily_count_check" CHECK ("daily_count" > 0);
+
+COMMENT ON COLUMN "transfer_limits"."max_amount" IS 'the largest single transfer, in minor units of currency';
+COMMENT ON COLUMN "transfer_limits"."daily_amount" IS 'the most a user can send from their accounts in currency in a UTC day';
+COMMENT ON COLUMN "transfer_limits"."daily_count" IS 'how many transfers a user can send from their accounts in currency in a UTC day';
+
+-- 10,000 a transfer and 25,000 in 50 transfers a day for standard users, ten times
+-- that for premium users, users of tiers without a row for a currency have no limit
+INSERT INTO "transfer_limits" ("tier", "currency", "max_amount", "daily_amount", "daily_count")
+SELECT t."tier", c."code",
+  (10000 * t."factor" * power(10, c."exponent"))::bigint,
+  (25000 * t."factor" * power(10, c."exponent"))::bigint,
+  50 * t."factor"
+FROM "currencies" c, (VALUES ('standard', 1), ('premium', 10)) AS t("tier", "factor");
@@ -0,0 +1,11 @@
+DELETE FROM "system_accounts" WHERE "purpose" = 'fees';
+
+DELETE FROM "accounts" a
+USING "users" u
+WHERE u."id" = a."user_id" AND u."username" = 'system'
+  AND NOT EXISTS (SELECT 1 FROM "system_accounts" s WHERE s."account_id" = a."id")
+  AND NOT EXISTS (SELECT 1 FROM "entries" e WHERE e."account_id" = a."id");
+
+COMMENT ON COLUMN "system_accounts"."purpose" IS 'cash is the counterpart of deposits and withdrawals, fx of currency conversions';
+
+DROP TABLE IF EXISTS "fee_rules";
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS "fee_rules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "scope" varchar NOT NULL,
+  "flat_amount" bigint NOT NULL DEFAULT 0,
+  "rate_bps" integer NOT NULL DEFAULT 0,
+  "min_amount" bigint NOT NULL DEFAULT 0,
+  "max_amount" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE IF EXISTS "fee_rules" ADD CONSTRAINT "fk_fee_rules_currencies" FOREIGN KEY ("currency") REFERENCES "currencies" ("code") ON UPDATE CASCADE;
+ALTER TABLE IF EXISTS "fee_rules" ADD CONSTRAINT "fee_rules_currency_scope_key" UNIQUE ("currency", "scope");
+ALTER TABLE IF EXISTS "fee_rules" ADD CONSTRAINT "fee_rules_scope_check" CHECK ("scope" IN ('own', 'cross_owner'));
+ALTER TABLE IF EXISTS "fee_rules" ADD CONSTRAINT "fee_rules_flat_amount_check" CHECK ("flat_amount" >= 0);
+ALTER TABLE IF EXISTS "fee_rules" ADD CONSTRAINT "fee_rules_rate_bps_check" CHECK ("rate_bps" BETWEEN 0 AND 10000);
+ALTER TABLE IF EXISTS "fee_rules" ADD CONSTRAINT "fee_rules_min_amount_check" CHECK ("min_amount" >= 0);
+ALTER TABLE IF EXISTS "fee_rules" ADD CONSTRAINT "fee_rules_max_amount_check" CHECK ("max_amount" IS NULL OR "max_amount" >= "min_amount");
+
+COMMENT ON COLUMN "fee_rules"."currency" IS 'the currency of the from accounts the rule applies to, fees are charged in it';
+COMMENT ON COLUMN "fee_rules"."scope" IS 'own for transfers between accounts of one user, cross_owner for transfers to another user';
+COMMENT ON COLUMN "fee_rules"."flat_amount" IS 'charged on every transfer, in minor units of currency';
+COMMENT ON COLUMN "fee_rules"."rate_bps" IS 'the percentage of the amount charged on top of flat_amount, in hundredths of a percent';
+COMMENT ON COLUMN "fee_rules"."min_amount" IS 'the smallest fee charged';
+COMMENT ON COLUMN "fee_rules"."max_amount" IS 'the largest fee charged, null for no cap';
+
+-- the fee revenue accounts are a third system account per currency
+WITH "fee_accounts" AS (
+  INSERT INTO "accounts" ("owner", "balance", "currency", "user_id", "overdraft_limit")
+  SELECT u."username", 0, c."code", u."id", NULL
+  FROM "users" u, "currencies" c
+  WHERE u."username" = 'system'
+  RETURNING "id", "currency"
+)
+INSERT INTO "system_accounts" ("purpose", "currency", "account_id")
+SELECT 'fees', "currency", "id" FROM "fee_accounts";
+
+COMMENT ON COLUMN "system_accounts"."purpose" IS 'cash is the counterpart of deposits and withdrawals, fx of currency conversions, fees collects transfer fees';
@@ -0,0 +1,11 @@
+ALTER TABLE IF EXISTS "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE IF EXISTS "transfers" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE IF EXISTS "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE IF EXISTS "transfers" ADD CONSTRAINT "transfers_metadata_check" CHECK (jsonb_typeof("metadata") = 'object');
+
+-- transfers are searched by reference, most have none
+CREATE INDEX IF NOT EXISTS "transfers_reference_idx" ON "transfers" ("reference") WHERE "reference" <> '';
+
+COMMENT ON COLUMN "transfers"."description" IS 'a memo shown to both sides of the transfer';
+COMMENT ON COLUMN "transfers"."reference" IS 'an identifier of the transfer in the systems of the client, not unique';
+COMMENT ON COLUMN "transfers"."metadata" IS 'a json object the client attached to the transfer, stored as is';
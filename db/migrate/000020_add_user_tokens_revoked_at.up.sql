@@ -0,0 +1,8 @@
+-- nothing is revoked by default, now() would reject tokens another server issued a
+-- moment earlier or with a clock slightly behind the database
+ALTER TABLE IF EXISTS "users" ADD COLUMN "tokens_revoked_at" timestamptz NOT NULL DEFAULT '1970-01-01 00:00:00Z';
+
+-- tokens issued before the last password change are already rejected
+UPDATE "users" SET "tokens_revoked_at" = "password_changed_at";
+
+COMMENT ON COLUMN "users"."tokens_revoked_at" IS 'every token of the user issued before this time is rejected, moved by password changes and session revocations';
@@ -0,0 +1,12 @@
+-- entries of the cash, fees and fx accounts wait here to be added to the account balance by
+-- a periodic sweep, every transfer would update the same rows otherwise
+CREATE TABLE IF NOT EXISTS "pending_postings" (
+  "entry_id" bigint PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL
+);
+
+ALTER TABLE IF EXISTS "pending_postings" ADD CONSTRAINT "fk_pending_postings_entries" FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+ALTER TABLE IF EXISTS "pending_postings" ADD CONSTRAINT "fk_pending_postings_accounts" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+COMMENT ON COLUMN "pending_postings"."amount" IS 'the amount of the entry, added to the balance of the account by the posting sweep';
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS "currencies_system_accounts" ON "currencies";
+DROP FUNCTION IF EXISTS "currencies_create_system_accounts"();
+DROP FUNCTION IF EXISTS "create_system_accounts"(varchar);
@@ -0,0 +1,34 @@
+-- every currency needs the bank's own accounts, the ones added after the migrations
+-- that created them get theirs when they are inserted
+CREATE OR REPLACE FUNCTION "create_system_accounts"(currency_code varchar) RETURNS void
+LANGUAGE plpgsql AS $$
+DECLARE
+  system_purpose varchar;
+  new_account_id bigint;
+BEGIN
+  FOREACH system_purpose IN ARRAY ARRAY['cash', 'fx', 'fees'] LOOP
+    CONTINUE WHEN EXISTS (
+      SELECT 1 FROM "system_accounts" WHERE "purpose" = system_purpose AND "currency" = currency_code
+    );
+
+    INSERT INTO "accounts" ("owner", "balance", "currency", "user_id", "overdraft_limit")
+    SELECT "username", 0, currency_code, "id", NULL FROM "users" WHERE "username" = 'system'
+    RETURNING "id" INTO new_account_id;
+
+    INSERT INTO "system_accounts" ("purpose", "currency", "account_id")
+    VALUES (system_purpose, currency_code, new_account_id);
+  END LOOP;
+END $$;
+
+CREATE OR REPLACE FUNCTION "currencies_create_system_accounts"() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  PERFORM "create_system_accounts"(NEW."code");
+  RETURN NEW;
+END $$;
+
+CREATE TRIGGER "currencies_system_accounts" AFTER INSERT ON "currencies"
+FOR EACH ROW EXECUTE FUNCTION "currencies_create_system_accounts"();
+
+-- currencies inserted since the system accounts were created
+SELECT "create_system_accounts"("code") FROM "currencies";
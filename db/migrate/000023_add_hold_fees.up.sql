@@ -0,0 +1,6 @@
+-- a hold reserves the fee of its capture with its amount, the fee rule in effect at the
+-- capture may be different
+ALTER TABLE IF EXISTS "holds" ADD COLUMN "fee_amount" bigint NOT NULL DEFAULT 0;
+ALTER TABLE IF EXISTS "holds" ADD CONSTRAINT "holds_fee_amount_check" CHECK ("fee_amount" >= 0);
+
+COMMENT ON COLUMN "holds"."fee_amount" IS 'the fee quoted when the hold was authorized, held on top of amount and the most its capture is charged';
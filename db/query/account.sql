@@ -11,6 +11,12 @@ SELECT * FROM accounts
 ORDER BY id
 OFFSET $1 LIMIT $2;
 
+-- name: ListAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner = $1
+ORDER BY id
+OFFSET $2 LIMIT $3;
+
 -- name: CreateAccount :one
 INSERT INTO accounts (
   owner, balance, currency, user_id
@@ -25,9 +31,28 @@ WHERE id = $1
 RETURNING *;
 
 -- name: EnoughAccountBalance :one
-SELECT (balance >= $1) FROM accounts WHERE id = $2;
+SELECT (overdraft_limit IS NULL OR balance - hold_amount + overdraft_limit >= $1) FROM accounts WHERE id = $2;
 
 -- name: AddAccountBalance :one
 UPDATE accounts SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: AddAccountHold :one
+UPDATE accounts SET hold_amount = hold_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts SET overdraft_limit = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts SET
+  status = sqlc.arg(status),
+  status_reason = sqlc.arg(status_reason),
+  status_changed_at = now(),
+  closed_at = CASE WHEN sqlc.arg(status) = 'closed' THEN now() ELSE closed_at END
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -6,10 +6,15 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM entries
 ORDER BY id OFFSET $1 LIMIT $2;
 
+-- name: ListEntriesByTransfer :many
+SELECT * FROM entries
+WHERE transfer_id = $1
+ORDER BY id;
+
 -- name: CreateEntry :one
 INSERT INTO entries (
-  account_id, amount
+  account_id, amount, transfer_id, type
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4
 )
 RETURNING *;
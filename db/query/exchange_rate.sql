@@ -0,0 +1,13 @@
+-- name: CreateExchangeRate :one
+INSERT INTO exchange_rates (
+  base_currency, quote_currency, rate, valid_from
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetExchangeRate :one
+SELECT * FROM exchange_rates
+WHERE base_currency = $1 AND quote_currency = $2 AND valid_from <= now()
+ORDER BY valid_from DESC
+LIMIT 1;
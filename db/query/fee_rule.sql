@@ -0,0 +1,3 @@
+-- name: GetFeeRule :one
+SELECT * FROM fee_rules
+WHERE currency = $1 AND scope = $2 LIMIT 1;
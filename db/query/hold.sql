@@ -0,0 +1,28 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  from_account_id, to_account_id, amount, currency, expires_at, fee_amount
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListExpiredHolds :many
+SELECT * FROM holds
+WHERE status = 'pending' AND expires_at <= sqlc.arg(now)
+ORDER BY id
+LIMIT sqlc.arg(limit_count)
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: UpdateHold :one
+UPDATE holds
+SET status = $2, captured_amount = $3, transfer_id = $4, updated_at = now()
+WHERE id = $1
+RETURNING *;
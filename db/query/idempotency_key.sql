@@ -0,0 +1,11 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+  username, key, request_hash, response
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING username, key, request_hash, response, created_at;
+
+-- name: GetIdempotencyKey :one
+SELECT username, key, request_hash, response, created_at FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1;
@@ -0,0 +1,20 @@
+-- name: CreatePendingPosting :exec
+INSERT INTO pending_postings (
+  entry_id, account_id, amount
+) VALUES (
+  $1, $2, $3
+);
+
+-- name: ApplyPendingPostings :many
+WITH applied AS (
+  DELETE FROM pending_postings
+  RETURNING account_id, amount
+), totals AS (
+  SELECT account_id, SUM(amount)::bigint AS amount
+  FROM applied
+  GROUP BY account_id
+)
+UPDATE accounts a SET balance = a.balance + t.amount
+FROM totals t
+WHERE a.id = t.account_id
+RETURNING a.*;
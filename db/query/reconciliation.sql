@@ -0,0 +1,24 @@
+-- name: ListAccountLedgerTotals :many
+SELECT a.id, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_total,
+  (SELECT COALESCE(SUM(p.amount), 0) FROM pending_postings p WHERE p.account_id = a.id)::bigint AS pending_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > sqlc.arg(after_id)
+GROUP BY a.id
+ORDER BY a.id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListTransferEntryCounts :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.to_amount,
+  (
+    SELECT COUNT(*) FROM entries e
+    WHERE e.transfer_id = t.id AND e.account_id = t.from_account_id AND e.amount = -t.amount AND e.type <> 'fee'
+  ) AS from_entries,
+  (
+    SELECT COUNT(*) FROM entries e
+    WHERE e.transfer_id = t.id AND e.account_id = t.to_account_id AND e.amount = t.to_amount AND e.type <> 'fee'
+  ) AS to_entries
+FROM transfers t
+WHERE t.id > sqlc.arg(after_id)
+ORDER BY t.id
+LIMIT sqlc.arg(limit_count);
@@ -0,0 +1,40 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  owner, from_account_id, to_account_id, amount, currency, frequency, starts_at, ends_at, next_run_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetScheduledTransferForUpdate :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListScheduledTransfersByOwner :many
+SELECT * FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id
+OFFSET $2 LIMIT $3;
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET amount = $2, ends_at = $3, next_run_at = $4, status = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateOpenScheduledTransfer :one
+UPDATE scheduled_transfers
+SET amount = sqlc.arg(amount), ends_at = sqlc.arg(ends_at), next_run_at = sqlc.arg(next_run_at), status = sqlc.arg(status)
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(seen_status) AND next_run_at = sqlc.arg(seen_next_run_at)
+RETURNING *;
+
+-- name: ClaimDueScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE status = 'active' AND next_run_at <= sqlc.arg(now)
+ORDER BY next_run_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
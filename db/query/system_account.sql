@@ -0,0 +1,4 @@
+-- name: GetSystemAccount :one
+SELECT a.* FROM accounts a
+JOIN system_accounts s ON s.account_id = a.id
+WHERE s.purpose = $1 AND s.currency = $2 LIMIT 1;
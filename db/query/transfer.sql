@@ -5,11 +5,18 @@ select * from transfers WHERE id = $1 LIMIT 1;
 select * from transfers ORDER BY created_at DESC OFFSET $1 LIMIT $2;
 
 -- name: CreateTransfer :one
-INSERT INTO transfers(from_account_id, to_account_id, amount)
-VALUES ($1, $2, $3)
+INSERT INTO transfers(from_account_id, to_account_id, amount, to_amount, exchange_rate, description, reference, metadata)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: ListTransfersByFrom :many
 select * from transfers
 WHERE from_account_id = $1
 ORDER BY created_at DESC OFFSET $2 LIMIT $3;
+
+-- name: SearchTransfers :many
+select * from transfers
+WHERE (from_account_id IN (SELECT id FROM accounts WHERE owner = sqlc.arg(owner))
+    OR to_account_id IN (SELECT id FROM accounts WHERE owner = sqlc.arg(owner)))
+  AND (sqlc.arg(reference)::varchar = '' OR reference = sqlc.arg(reference))
+ORDER BY id DESC OFFSET sqlc.arg(offset_count) LIMIT sqlc.arg(limit_count);
@@ -0,0 +1,19 @@
+-- name: GetTransferLimit :one
+SELECT l.* FROM transfer_limits l
+JOIN users u ON u.tier = l.tier
+WHERE u.id = sqlc.arg(user_id) AND l.currency = sqlc.arg(currency) LIMIT 1;
+
+-- name: ListTransferLimitsByTier :many
+SELECT * FROM transfer_limits
+WHERE tier = $1
+ORDER BY currency;
+
+-- name: GetDailyTransferUsage :one
+SELECT count(DISTINCT t.id) AS transfer_count, COALESCE(sum(t.amount), 0)::bigint AS total_amount
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.user_id = sqlc.arg(user_id) AND a.currency = sqlc.arg(currency) AND t.created_at >= sqlc.arg(since)
+  AND EXISTS (
+    SELECT 1 FROM entries e
+    WHERE e.transfer_id = t.id AND e.account_id = t.from_account_id AND e.type = 'transfer'
+  );
@@ -0,0 +1,15 @@
+-- name: CreateTransferReversal :one
+INSERT INTO transfer_reversals (
+  transfer_id, reversal_transfer_id, reversed_by, reason
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetTransferReversal :one
+SELECT * FROM transfer_reversals
+WHERE transfer_id = $1 LIMIT 1;
+
+-- name: GetTransferReversalByReversalTransfer :one
+SELECT * FROM transfer_reversals
+WHERE reversal_transfer_id = $1 LIMIT 1;
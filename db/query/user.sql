@@ -13,3 +13,31 @@ INSERT INTO users (
   $1, $2, $3, $4
 )
 RETURNING *;
+
+-- name: GetUserTokensRevokedAt :one
+SELECT tokens_revoked_at FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2, password_changed_at = now(), tokens_revoked_at = now(), updated_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: PromoteFirstAdmin :one
+UPDATE users
+SET role = 'admin', updated_at = now()
+WHERE username = $1 AND NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin')
+RETURNING *;
+
+-- name: RevokeUserTokens :one
+UPDATE users
+SET tokens_revoked_at = now(), updated_at = now()
+WHERE username = $1
+RETURNING tokens_revoked_at;
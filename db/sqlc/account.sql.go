@@ -5,12 +5,13 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at, user_id
+RETURNING id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit
 `
 
 type AddAccountBalanceParams struct {
@@ -28,6 +29,43 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Currency,
 		&i.CreatedAt,
 		&i.UserID,
+		&i.HoldAmount,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.ClosedAt,
+		&i.OverdraftLimit,
+	)
+	return i, err
+}
+
+const addAccountHold = `-- name: AddAccountHold :one
+UPDATE accounts SET hold_amount = hold_amount + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit
+`
+
+type AddAccountHoldParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddAccountHold(ctx context.Context, arg AddAccountHoldParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountHold, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.HoldAmount,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.ClosedAt,
+		&i.OverdraftLimit,
 	)
 	return i, err
 }
@@ -38,7 +76,7 @@ INSERT INTO accounts (
 ) VALUES (
   $1, $2, $3, $4
 )
-RETURNING id, owner, balance, currency, created_at, user_id
+RETURNING id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit
 `
 
 type CreateAccountParams struct {
@@ -63,12 +101,18 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Currency,
 		&i.CreatedAt,
 		&i.UserID,
+		&i.HoldAmount,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.ClosedAt,
+		&i.OverdraftLimit,
 	)
 	return i, err
 }
 
 const enoughAccountBalance = `-- name: EnoughAccountBalance :one
-SELECT (balance >= $1) FROM accounts WHERE id = $2
+SELECT (overdraft_limit IS NULL OR balance - hold_amount + overdraft_limit >= $1) FROM accounts WHERE id = $2
 `
 
 type EnoughAccountBalanceParams struct {
@@ -84,7 +128,7 @@ func (q *Queries) EnoughAccountBalance(ctx context.Context, arg EnoughAccountBal
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at, user_id FROM accounts
+SELECT id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -98,12 +142,18 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Currency,
 		&i.CreatedAt,
 		&i.UserID,
+		&i.HoldAmount,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.ClosedAt,
+		&i.OverdraftLimit,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at, user_id FROM accounts
+SELECT id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit FROM accounts
 WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE
 `
 
@@ -117,12 +167,18 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Currency,
 		&i.CreatedAt,
 		&i.UserID,
+		&i.HoldAmount,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.ClosedAt,
+		&i.OverdraftLimit,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at, user_id FROM accounts
+SELECT id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit FROM accounts
 ORDER BY id
 OFFSET $1 LIMIT $2
 `
@@ -148,6 +204,61 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Currency,
 			&i.CreatedAt,
 			&i.UserID,
+			&i.HoldAmount,
+			&i.Status,
+			&i.StatusReason,
+			&i.StatusChangedAt,
+			&i.ClosedAt,
+			&i.OverdraftLimit,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByOwner = `-- name: ListAccountsByOwner :many
+SELECT id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit FROM accounts
+WHERE owner = $1
+ORDER BY id
+OFFSET $2 LIMIT $3
+`
+
+type ListAccountsByOwnerParams struct {
+	Owner  string `json:"owner"`
+	Offset int32  `json:"offset"`
+	Limit  int32  `json:"limit"`
+}
+
+func (q *Queries) ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsByOwner, arg.Owner, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.HoldAmount,
+			&i.Status,
+			&i.StatusReason,
+			&i.StatusChangedAt,
+			&i.ClosedAt,
+			&i.OverdraftLimit,
 		); err != nil {
 			return nil, err
 		}
@@ -165,7 +276,7 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at, user_id
+RETURNING id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit
 `
 
 type UpdateAccountParams struct {
@@ -183,6 +294,79 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Currency,
 		&i.CreatedAt,
 		&i.UserID,
+		&i.HoldAmount,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.ClosedAt,
+		&i.OverdraftLimit,
+	)
+	return i, err
+}
+
+const updateAccountOverdraftLimit = `-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts SET overdraft_limit = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	ID             int64         `json:"id"`
+	OverdraftLimit sql.NullInt64 `json:"overdraft_limit"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOverdraftLimit, arg.ID, arg.OverdraftLimit)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.HoldAmount,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.ClosedAt,
+		&i.OverdraftLimit,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts SET
+  status = $1,
+  status_reason = $2,
+  status_changed_at = now(),
+  closed_at = CASE WHEN $1 = 'closed' THEN now() ELSE closed_at END
+WHERE id = $3
+RETURNING id, owner, balance, currency, created_at, user_id, hold_amount, status, status_reason, status_changed_at, closed_at, overdraft_limit
+`
+
+type UpdateAccountStatusParams struct {
+	Status       string `json:"status"`
+	StatusReason string `json:"status_reason"`
+	ID           int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.Status, arg.StatusReason, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.HoldAmount,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.ClosedAt,
+		&i.OverdraftLimit,
 	)
 	return i, err
 }
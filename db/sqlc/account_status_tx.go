@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// statuses of an account, only active accounts send and receive money and closing is final
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// checkActive fail with ErrAccountFrozen or ErrAccountClosed unless every account is active
+func checkActive(accounts ...Account) error {
+	for _, account := range accounts {
+		switch account.Status {
+		case AccountStatusFrozen:
+			return fmt.Errorf("%w: account [%v]", ErrAccountFrozen, account.ID)
+		case AccountStatusClosed:
+			return fmt.Errorf("%w: account [%v]", ErrAccountClosed, account.ID)
+		}
+	}
+	return nil
+}
+
+// systemUsername owns the bank's own accounts listed in system_accounts
+const systemUsername = "system"
+
+type ChangeAccountStatusParams struct {
+	AccountID int64
+	Status    string
+	Reason    string
+	// RequireActive refuses to change a frozen account with ErrAccountFrozen, so
+	// an owner can't close an account the bank froze
+	RequireActive bool
+}
+
+// ChangeAccountStatusTx move an account to arg.Status. A closed account can't change
+// status anymore and an account can only be closed once it has no balance nor pending
+// holds, failing with ErrAccountNotEmpty otherwise. The bank's own accounts keep their
+// status, freezing one would stop every deposit, conversion or fee in its currency
+func (store *SQLStore) ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusParams) (Account, error) {
+	var account Account
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		// the lock keeps transfers from moving funds while the account is closed
+		account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrAccountNotFound
+			}
+			return err
+		}
+
+		if account.Owner == systemUsername {
+			return fmt.Errorf("%w: account [%v]", ErrSystemAccount, account.ID)
+		}
+		if account.Status == AccountStatusClosed {
+			return fmt.Errorf("%w: account [%v]", ErrAccountClosed, account.ID)
+		}
+		if arg.RequireActive && account.Status == AccountStatusFrozen {
+			return fmt.Errorf("%w: account [%v]", ErrAccountFrozen, account.ID)
+		}
+		if arg.Status == AccountStatusClosed && (account.Balance != 0 || account.HoldAmount != 0) {
+			return ErrAccountNotEmpty
+		}
+
+		account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:           arg.AccountID,
+			Status:       arg.Status,
+			StatusReason: arg.Reason,
+		})
+		return err
+	})
+
+	return account, err
+}
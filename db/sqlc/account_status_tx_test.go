@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func changeAccountStatus(t *testing.T, store Store, account Account, status string) Account {
+	changed, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusParams{
+		AccountID: account.ID,
+		Status:    status,
+		Reason:    "test",
+	})
+	require.NoError(t, err)
+	require.Equal(t, status, changed.Status)
+	require.Equal(t, "test", changed.StatusReason)
+	require.True(t, changed.StatusChangedAt.After(account.StatusChangedAt))
+	return changed
+}
+
+func TestFrozenAccountTransfer(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	arg := TransferParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 1}
+
+	from = changeAccountStatus(t, store, from, AccountStatusFrozen)
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// credits are refused as well
+	_, err = store.TransferTx(context.Background(), TransferParams{FromAccountID: to.ID, ToAccountID: from.ID, Amount: 1})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	changeAccountStatus(t, store, from, AccountStatusActive)
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+}
+
+func TestCloseAccount(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	_, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusParams{
+		AccountID: account.ID,
+		Status:    AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	account, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+
+	closed := changeAccountStatus(t, store, account, AccountStatusClosed)
+	require.True(t, closed.ClosedAt.Valid)
+
+	_, err = store.TransferTx(context.Background(), TransferParams{FromAccountID: other.ID, ToAccountID: closed.ID, Amount: 1})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	// closing is final
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusParams{
+		AccountID: closed.ID,
+		Status:    AccountStatusActive,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+}
+
+func TestCloseFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+
+	frozen := changeAccountStatus(t, store, account, AccountStatusFrozen)
+
+	// the owner can't close an account the bank froze
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusParams{
+		AccountID:     frozen.ID,
+		Status:        AccountStatusClosed,
+		RequireActive: true,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// the bank can
+	changeAccountStatus(t, store, frozen, AccountStatusClosed)
+}
+
+func TestChangeSystemAccountStatus(t *testing.T) {
+	store := NewStore(testDB)
+	cash, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemPurposeCash,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusParams{
+		AccountID: cash.ID,
+		Status:    AccountStatusFrozen,
+		Reason:    "test",
+	})
+	require.ErrorIs(t, err, ErrSystemAccount)
+}
@@ -85,3 +85,24 @@ func TestAddAccountBalance(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, account2.Balance, args.Amount+account1.Balance)
 }
+
+func TestListAccountsByOwner(t *testing.T) {
+	var lastAccount Account
+	for i := 0; i < 10; i++ {
+		lastAccount = createRandomAccount(t)
+	}
+	args := ListAccountsByOwnerParams{
+		Owner:  lastAccount.Owner,
+		Offset: 0,
+		Limit:  5,
+	}
+	lstAccounts, err := testQueries.ListAccountsByOwner(context.Background(), args)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, lstAccounts)
+
+	for _, account := range lstAccounts {
+		require.NotEmpty(t, account)
+		require.Equal(t, lastAccount.Owner, account.Owner)
+	}
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+type BatchTransferItem struct {
+	ToAccountID int64
+	Amount      int64
+}
+
+type BatchTransferParams struct {
+	FromAccountID int64
+	Items         []BatchTransferItem
+	// Atomic make every transfer of the batch or none of them
+	Atomic bool
+}
+
+// BatchTransferItemResult is the outcome of one transfer of a batch, Err is set
+// instead of Result when the transfer failed
+type BatchTransferItemResult struct {
+	Result TransferResult
+	Err    error
+}
+
+type BatchTransferResult struct {
+	Items []BatchTransferItemResult
+}
+
+// BatchItemError is the failure of the transfer at Index that rolled an atomic batch
+// back, it unwraps to the error of that transfer
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("batch transfer %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchTransferTx make a transfer from arg.FromAccountID to each item, failing with
+// ErrInsufficientFunds before any transfer when the account doesn't cover the total
+// of the amounts and their fees.
+// An atomic batch runs in one transaction and fails with a *BatchItemError on the first
+// transfer that fails, otherwise each transfer is made by TransferTx on its own and
+// its failure is reported in its item result
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferParams) (BatchTransferResult, error) {
+	if arg.Atomic {
+		var result BatchTransferResult
+		err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+			total, err := batchTotal(ctx, q, arg)
+			if err != nil {
+				return err
+			}
+			if err := checkBalance(ctx, q, arg.FromAccountID, total); err != nil {
+				return err
+			}
+
+			result.Items = make([]BatchTransferItemResult, len(arg.Items))
+			for i, item := range arg.Items {
+				transferred, err := transfer(ctx, q, TransferParams{
+					FromAccountID: arg.FromAccountID,
+					ToAccountID:   item.ToAccountID,
+					Amount:        item.Amount,
+				}, EntryTypeTransfer)
+				if err != nil {
+					return &BatchItemError{Index: i, Err: err}
+				}
+				result.Items[i].Result = transferred
+			}
+			return nil
+		})
+
+		return result, err
+	}
+
+	// the funds can still run out part way through when the account is debited concurrently
+	total, err := batchTotal(ctx, store.Queries, arg)
+	if err != nil {
+		return BatchTransferResult{}, err
+	}
+	if err := checkBalance(ctx, store.Queries, arg.FromAccountID, total); err != nil {
+		return BatchTransferResult{}, err
+	}
+
+	result := BatchTransferResult{Items: make([]BatchTransferItemResult, len(arg.Items))}
+	for i, item := range arg.Items {
+		result.Items[i].Result, result.Items[i].Err = store.TransferTx(ctx, TransferParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   item.ToAccountID,
+			Amount:        item.Amount,
+		})
+	}
+	return result, nil
+}
+
+// batchTotal return what the items of arg would debit from its from account, their
+// amounts and fees. An item to an account that doesn't exist counts without a fee as
+// its transfer fails on its own
+func batchTotal(ctx context.Context, q *Queries, arg BatchTransferParams) (int64, error) {
+	from, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrAccountNotFound
+		}
+		return 0, err
+	}
+
+	var total int64
+	for _, item := range arg.Items {
+		debit := item.Amount
+
+		to, err := q.GetAccount(ctx, item.ToAccountID)
+		switch {
+		case err == nil:
+			fee, err := quoteFee(ctx, q, from, to, item.Amount)
+			if err != nil {
+				return 0, err
+			}
+			if fee.Amount > math.MaxInt64-debit {
+				return 0, ErrInsufficientFunds
+			}
+			debit += fee.Amount
+		case err != sql.ErrNoRows:
+			return 0, err
+		}
+
+		if debit > math.MaxInt64-total {
+			return 0, ErrInsufficientFunds
+		}
+		total += debit
+	}
+	return total, nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferTxAtomic(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to1 := createRandomAccount(t)
+	to2 := createRandomAccount(t)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferParams{
+		FromAccountID: from.ID,
+		Items: []BatchTransferItem{
+			{ToAccountID: to1.ID, Amount: 10},
+			{ToAccountID: to2.ID, Amount: 20},
+		},
+		Atomic: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	for _, item := range result.Items {
+		require.NoError(t, item.Err)
+		require.NotZero(t, item.Result.Transfer.ID)
+	}
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance-30, account.Balance)
+}
+
+func TestBatchTransferTxAtomicRollback(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferParams{
+		FromAccountID: from.ID,
+		Items: []BatchTransferItem{
+			{ToAccountID: to.ID, Amount: 10},
+			{ToAccountID: -1, Amount: 10},
+		},
+		Atomic: true,
+	})
+	var itemErr *BatchItemError
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 1, itemErr.Index)
+	require.ErrorIs(t, err, ErrAccountNotFound)
+
+	// the first transfer was rolled back with the second
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, account.Balance)
+}
+
+func TestBatchTransferTxBestEffort(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferParams{
+		FromAccountID: from.ID,
+		Items: []BatchTransferItem{
+			{ToAccountID: to.ID, Amount: 10},
+			{ToAccountID: -1, Amount: 10},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, result.Items[0].Err)
+	require.ErrorIs(t, result.Items[1].Err, ErrAccountNotFound)
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance-10, account.Balance)
+}
+
+func TestBatchTransferTxInsufficientTotal(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferParams{
+		FromAccountID: from.ID,
+		Items: []BatchTransferItem{
+			{ToAccountID: to.ID, Amount: from.Balance},
+			{ToAccountID: to.ID, Amount: 1},
+		},
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestBatchTransferTxInsufficientTotalWithFees(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	for to.Currency != from.Currency {
+		to = createRandomAccount(t)
+	}
+
+	from, err := store.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: from.ID, Amount: 100})
+	require.NoError(t, err)
+
+	_, err = testDB.Exec(`INSERT INTO fee_rules (currency, scope, flat_amount, rate_bps) VALUES ($1, $2, 5, 0)`,
+		from.Currency, FeeScopeCrossOwner)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := testDB.Exec(`DELETE FROM fee_rules WHERE currency = $1 AND scope = $2`, from.Currency, FeeScopeCrossOwner)
+		require.NoError(t, err)
+	})
+
+	// the balance covers the amounts but not their fees
+	for _, atomic := range []bool{true, false} {
+		_, err = store.BatchTransferTx(context.Background(), BatchTransferParams{
+			FromAccountID: from.ID,
+			Items: []BatchTransferItem{
+				{ToAccountID: to.ID, Amount: from.Balance - 1},
+				{ToAccountID: to.ID, Amount: 1},
+			},
+			Atomic: atomic,
+		})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+	}
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, account.Balance)
+}
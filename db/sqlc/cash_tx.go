@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SystemPurposeCash is the purpose of the system account in each currency that
+// deposits come from and withdrawals go to
+const SystemPurposeCash = "cash"
+
+type CashParams struct {
+	AccountID int64
+	Amount    int64
+	// Currency must be the currency of the account
+	Currency string
+}
+
+type CashResult struct {
+	Account  Account
+	Transfer Transfer
+	Entry    Entry
+}
+
+// DepositTx credit amount to an account from the cash account of its currency
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashParams) (CashResult, error) {
+	var result CashResult
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+		account, cash, err := cashAccounts(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		// the cash account is the bank's side of the ledger and goes negative
+		transfer, err := postTransfer(ctx, q, TransferParams{
+			FromAccountID: cash.ID,
+			ToAccountID:   account.ID,
+			Amount:        arg.Amount,
+		}, sameCurrency(arg.Amount), EntryTypeDeposit)
+		if err != nil {
+			return err
+		}
+
+		result = CashResult{Account: transfer.ToAccount, Transfer: transfer.Transfer, Entry: transfer.ToEntry}
+		return nil
+	})
+
+	return result, err
+}
+
+// WithdrawTx debit amount from an account to the cash account of its currency,
+// failing with ErrInsufficientFunds when the balance doesn't cover it
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashParams) (CashResult, error) {
+	var result CashResult
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+		account, cash, err := cashAccounts(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		transfer, err := transfer(ctx, q, TransferParams{
+			FromAccountID: account.ID,
+			ToAccountID:   cash.ID,
+			Amount:        arg.Amount,
+		}, EntryTypeWithdrawal)
+		if err != nil {
+			return err
+		}
+
+		result = CashResult{Account: transfer.FromAccount, Transfer: transfer.Transfer, Entry: transfer.FromEntry}
+		return nil
+	})
+
+	return result, err
+}
+
+// cashAccounts return the account of arg and the cash account of its currency, the
+// account must be active
+func cashAccounts(ctx context.Context, q *Queries, arg CashParams) (Account, Account, error) {
+	account, err := q.GetAccount(ctx, arg.AccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, Account{}, ErrAccountNotFound
+		}
+		return account, Account{}, err
+	}
+	if account.Currency != arg.Currency {
+		return account, Account{}, fmt.Errorf("%w: account [%v] is in %v not %v", ErrCurrencyMismatch, account.ID, account.Currency, arg.Currency)
+	}
+	if err := checkActive(account); err != nil {
+		return account, Account{}, err
+	}
+
+	cash, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+		Purpose:  SystemPurposeCash,
+		Currency: account.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// a setup problem rather than a missing resource of the request
+			return account, cash, fmt.Errorf("no %s account for currency %s", SystemPurposeCash, account.Currency)
+		}
+		return account, cash, err
+	}
+
+	return account, cash, nil
+}
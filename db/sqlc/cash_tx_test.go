@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepositAndWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	cash, err := store.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemPurposeCash,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+
+	deposit, err := store.DepositTx(context.Background(), CashParams{AccountID: account.ID, Amount: 50, Currency: account.Currency})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+50, deposit.Account.Balance)
+	require.Equal(t, cash.ID, deposit.Transfer.FromAccountID)
+	require.Equal(t, EntryTypeDeposit, deposit.Entry.Type)
+	require.Equal(t, int64(50), deposit.Entry.Amount)
+
+	withdrawal, err := store.WithdrawTx(context.Background(), CashParams{AccountID: account.ID, Amount: 20, Currency: account.Currency})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+30, withdrawal.Account.Balance)
+	require.Equal(t, cash.ID, withdrawal.Transfer.ToAccountID)
+	require.Equal(t, EntryTypeWithdrawal, withdrawal.Entry.Type)
+	require.Equal(t, int64(-20), withdrawal.Entry.Amount)
+
+	// the cash account is posted to by the posting sweep
+	pending, err := store.GetAccount(context.Background(), cash.ID)
+	require.NoError(t, err)
+	require.Equal(t, cash.Balance, pending.Balance)
+
+	_, err = store.ApplyPendingPostings(context.Background())
+	require.NoError(t, err)
+
+	cash, err = store.GetAccount(context.Background(), cash.ID)
+	require.NoError(t, err)
+	var entriesTotal int64
+	err = testDB.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM entries WHERE account_id = $1`, cash.ID).Scan(&entriesTotal)
+	require.NoError(t, err)
+	require.Equal(t, entriesTotal, cash.Balance)
+
+	_, err = store.WithdrawTx(context.Background(), CashParams{AccountID: account.ID, Amount: withdrawal.Account.Balance + 1, Currency: account.Currency})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.DepositTx(context.Background(), CashParams{AccountID: -1, Amount: 50, Currency: util.USD})
+	require.ErrorIs(t, err, ErrAccountNotFound)
+}
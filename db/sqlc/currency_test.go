@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	codes := make([]string, len(currencies))
+	for i, currency := range currencies {
+		codes[i] = currency.Code
+	}
+	require.Subset(t, codes, []string{util.USD, util.SAR, util.EGP, util.EUR})
+}
+
+func TestCreateCurrencySystemAccounts(t *testing.T) {
+	code := "X" + strings.ToUpper(util.RandomString(2))
+	_, err := testDB.Exec(`INSERT INTO currencies (code, exponent, enabled) VALUES ($1, 2, false)`, code)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := testDB.Exec(`DELETE FROM system_accounts WHERE currency = $1`, code)
+		require.NoError(t, err)
+		_, err = testDB.Exec(`DELETE FROM accounts WHERE currency = $1`, code)
+		require.NoError(t, err)
+		_, err = testDB.Exec(`DELETE FROM currencies WHERE code = $1`, code)
+		require.NoError(t, err)
+	})
+
+	for _, purpose := range []string{SystemPurposeCash, SystemPurposeFX, SystemPurposeFees} {
+		account, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+			Purpose:  purpose,
+			Currency: code,
+		})
+		require.NoError(t, err, purpose)
+		require.Equal(t, "system", account.Owner)
+		require.Zero(t, account.Balance)
+		require.False(t, account.OverdraftLimit.Valid)
+	}
+}
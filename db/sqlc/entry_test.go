@@ -15,6 +15,7 @@ func createRandomEntry(t *testing.T) Entry {
 	args := CreateEntryParams{
 		AccountID: account.ID,
 		Amount:    util.RandomEntryAmount(),
+		Type:      EntryTypeDeposit,
 	}
 
 	entry, err := testQueries.CreateEntry(context.Background(), args)
@@ -23,6 +24,8 @@ func createRandomEntry(t *testing.T) Entry {
 	require.NotEmpty(t, entry)
 	require.Equal(t, args.AccountID, entry.AccountID)
 	require.Equal(t, args.Amount, entry.Amount)
+	require.Equal(t, args.Type, entry.Type)
+	require.False(t, entry.TransferID.Valid)
 
 	return entry
 }
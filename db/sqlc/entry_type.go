@@ -0,0 +1,11 @@
+package db
+
+// entry types recorded on entries.type
+const (
+	EntryTypeTransfer   = "transfer"
+	EntryTypeDeposit    = "deposit"
+	EntryTypeWithdrawal = "withdrawal"
+	EntryTypeFee        = "fee"
+	EntryTypeInterest   = "interest"
+	EntryTypeReversal   = "reversal"
+)
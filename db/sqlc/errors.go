@@ -0,0 +1,104 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// domain errors returned by the store, callers match them with errors.Is
+var (
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrInsufficientFunds     = errors.New("insufficient funds")
+	ErrCurrencyMismatch      = errors.New("currency mismatch")
+	ErrAccountFrozen         = errors.New("account is frozen")
+	ErrAccountClosed         = errors.New("account is closed")
+	ErrAccountNotEmpty       = errors.New("account has a balance or pending holds")
+	ErrOverdraftInUse        = errors.New("balance is below the new overdraft limit")
+	ErrSystemAccount         = errors.New("account belongs to the bank")
+	ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+	ErrAlreadyReversed       = errors.New("transfer has already been reversed")
+	ErrReversalNotReversible = errors.New("a reversal can't be reversed")
+	ErrNoExchangeRate        = errors.New("no exchange rate in effect")
+	ErrAmountTooSmall        = errors.New("amount is too small to convert")
+	ErrHoldNotPending        = errors.New("hold was already captured, voided or expired")
+	ErrHoldExpired           = errors.New("hold has expired")
+	ErrCaptureExceedsHold    = errors.New("capture exceeds the held amount")
+	ErrUniqueViolation       = errors.New("unique violation")
+	ErrForeignKeyViolation   = errors.New("foreign key violation")
+
+	// ErrIdempotencyKeyExists is returned by IdempotentTransferTx when the key was
+	// stored by a concurrent request, the transfer of this call is rolled back
+	ErrIdempotencyKeyExists = errors.New("idempotency key already used")
+
+	// ErrNoScheduledTransferDue is returned by RunScheduledTransferTx when no active
+	// schedule is due or every due schedule is claimed by another scheduler
+	ErrNoScheduledTransferDue = errors.New("no scheduled transfer is due")
+
+	// ErrTxConflict is matched by a *TxConflictError, the transaction was rolled back
+	// and the same call can succeed once the contention is over
+	ErrTxConflict = errors.New("transaction conflicted with concurrent transactions")
+)
+
+// ConstraintError is a postgres constraint violation, it unwraps to
+// ErrUniqueViolation or ErrForeignKeyViolation
+type ConstraintError struct {
+	Kind       error
+	Table      string
+	Constraint string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%v on %s (%s)", e.Kind, e.Table, e.Constraint)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Kind
+}
+
+// TxConflictError is returned when a transaction still fails with a serialization
+// failure or a deadlock after every retry, it matches ErrTxConflict and unwraps
+// to the postgres error of the last attempt
+type TxConflictError struct {
+	Reason   string
+	Attempts int
+	Err      error
+}
+
+func (e *TxConflictError) Error() string {
+	return fmt.Sprintf("%v: %s after %d attempts", ErrTxConflict, e.Reason, e.Attempts)
+}
+
+func (e *TxConflictError) Is(target error) bool {
+	return target == ErrTxConflict
+}
+
+func (e *TxConflictError) Unwrap() error {
+	return e.Err
+}
+
+// balanceConstraint keeps the balance of an account above its overdraft limit
+const balanceConstraint = "accounts_balance_check"
+
+// ParseError decode constraint violations reported by postgres into a *ConstraintError,
+// or ErrInsufficientFunds for an account overdrawn past its limit, any other error is
+// returned unchanged
+func ParseError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		return &ConstraintError{Kind: ErrUniqueViolation, Table: pqErr.Table, Constraint: pqErr.Constraint}
+	case "foreign_key_violation":
+		return &ConstraintError{Kind: ErrForeignKeyViolation, Table: pqErr.Table, Constraint: pqErr.Constraint}
+	case "check_violation":
+		if pqErr.Constraint == balanceConstraint {
+			return ErrInsufficientFunds
+		}
+	}
+	return err
+}
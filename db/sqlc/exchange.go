@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+)
+
+// SystemPurposeFX is the purpose of the system account in each currency that takes
+// the other side of currency conversions, it keeps what rounding leaves over. Its
+// postings are deferred to the posting sweep as every conversion goes through it
+const SystemPurposeFX = "fx"
+
+// exchangeRateScale is the number of decimals rates derived by the store are kept with
+const exchangeRateScale = 10
+
+// conversion is how a transfer credits its to account, toAmount is in the currency of
+// the to account and the house accounts are set only when the currencies differ
+type conversion struct {
+	rate        string
+	toAmount    int64
+	fromHouseID int64
+	toHouseID   int64
+}
+
+// sameCurrency is the conversion of a transfer between accounts of one currency
+func sameCurrency(amount int64) conversion {
+	return conversion{rate: "1", toAmount: amount}
+}
+
+func (c conversion) exchanged() bool {
+	return c.fromHouseID != 0
+}
+
+// convert price amount of from in the currency of to at the exchange rate in effect,
+// rounding down so the bank never pays out more than it was paid
+func convert(ctx context.Context, q *Queries, from Account, to Account, amount int64) (conversion, error) {
+	if from.Currency == to.Currency {
+		return sameCurrency(amount), nil
+	}
+
+	rate, err := q.GetExchangeRate(ctx, GetExchangeRateParams{
+		BaseCurrency:  from.Currency,
+		QuoteCurrency: to.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return conversion{}, fmt.Errorf("%w from %s to %s", ErrNoExchangeRate, from.Currency, to.Currency)
+		}
+		return conversion{}, err
+	}
+
+	toAmount, err := applyRate(amount, rate.Rate)
+	if err != nil {
+		return conversion{}, err
+	}
+
+	return exchange(ctx, q, from.Currency, to.Currency, rate.Rate, toAmount)
+}
+
+// reverseConversion return the transfer and conversion that move original back from
+// its to account from to its from account to, the amounts are swapped so the sender
+// gets back exactly what was taken
+func reverseConversion(ctx context.Context, q *Queries, original Transfer, from Account, to Account) (TransferParams, conversion, error) {
+	arg := TransferParams{
+		FromAccountID: original.ToAccountID,
+		ToAccountID:   original.FromAccountID,
+		Amount:        original.ToAmount,
+	}
+
+	if from.Currency == to.Currency {
+		return arg, sameCurrency(original.Amount), nil
+	}
+
+	rate := new(big.Rat).SetFrac64(original.Amount, original.ToAmount)
+	conv, err := exchange(ctx, q, from.Currency, to.Currency, rate.FloatString(exchangeRateScale), original.Amount)
+	return arg, conv, err
+}
+
+// exchange build the conversion of toAmount between the fx house accounts of the
+// two currencies
+func exchange(ctx context.Context, q *Queries, fromCurrency string, toCurrency string, rate string, toAmount int64) (conversion, error) {
+	conv := conversion{rate: rate, toAmount: toAmount}
+	for _, house := range []struct {
+		currency string
+		id       *int64
+	}{
+		{fromCurrency, &conv.fromHouseID},
+		{toCurrency, &conv.toHouseID},
+	} {
+		account, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+			Purpose:  SystemPurposeFX,
+			Currency: house.currency,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return conv, fmt.Errorf("no %s account for currency %s", SystemPurposeFX, house.currency)
+			}
+			return conv, err
+		}
+		*house.id = account.ID
+	}
+
+	return conv, nil
+}
+
+// transferAccounts return the from and to accounts of a transfer
+func transferAccounts(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (Account, Account, error) {
+	from, err := q.GetAccount(ctx, fromAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return from, Account{}, ErrAccountNotFound
+		}
+		return from, Account{}, err
+	}
+
+	to, err := q.GetAccount(ctx, toAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return from, to, ErrAccountNotFound
+		}
+		return from, to, err
+	}
+
+	return from, to, nil
+}
+
+// applyRate return amount times the decimal rate rounded down
+func applyRate(amount int64, rate string) (int64, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid exchange rate %q", rate)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt64(amount))
+	converted := new(big.Int).Quo(r.Num(), r.Denom())
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("converting %d at %s overflows", amount, rate)
+	}
+	if converted.Sign() <= 0 {
+		return 0, ErrAmountTooSmall
+	}
+	return converted.Int64(), nil
+}
+
+// CreateExchangeRatesTx store a batch of rates, none is stored if one fails
+func (store *SQLStore) CreateExchangeRatesTx(ctx context.Context, args []CreateExchangeRateParams) ([]ExchangeRate, error) {
+	rates := make([]ExchangeRate, 0, len(args))
+	err := store.execTx(ctx, func(q *Queries) error {
+		for _, arg := range args {
+			rate, err := q.CreateExchangeRate(ctx, arg)
+			if err != nil {
+				return ParseError(err)
+			}
+			rates = append(rates, rate)
+		}
+		return nil
+	})
+
+	return rates, err
+}
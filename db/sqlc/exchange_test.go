@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		amount int64
+		rate   string
+		want   int64
+		err    error
+	}{
+		{name: "Whole", amount: 100, rate: "2", want: 200},
+		{name: "RoundsDown", amount: 10, rate: "0.3333", want: 3},
+		{name: "TooSmall", amount: 1, rate: "0.5", err: ErrAmountTooSmall},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := applyRate(tc.amount, tc.rate)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := applyRate(10, "-1")
+	require.Error(t, err)
+}
+
+func TestTransferTxExchange(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	for to.Currency == from.Currency {
+		to = createRandomAccount(t)
+	}
+
+	_, err := store.CreateExchangeRatesTx(context.Background(), []CreateExchangeRateParams{{
+		BaseCurrency:  from.Currency,
+		QuoteCurrency: to.Currency,
+		Rate:          "1.5",
+		ValidFrom:     time.Now().Add(-time.Second),
+	}})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        11,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(11), result.Transfer.Amount)
+	require.Equal(t, int64(16), result.Transfer.ToAmount)
+	require.Equal(t, "1.5", result.Transfer.ExchangeRate)
+	require.Equal(t, from.Balance-11, result.FromAccount.Balance)
+	require.Equal(t, to.Balance+16, result.ToAccount.Balance)
+
+	journal, err := GetTransferJournal(context.Background(), store, result.Transfer.ID)
+	require.NoError(t, err)
+	require.Len(t, journal.Entries, 4)
+
+	// the fx accounts are left to the posting sweep
+	var pending int
+	err = testDB.QueryRow(`SELECT COUNT(*) FROM pending_postings p JOIN entries e ON e.id = p.entry_id WHERE e.transfer_id = $1`,
+		result.Transfer.ID).Scan(&pending)
+	require.NoError(t, err)
+	require.Equal(t, 2, pending)
+
+	// the reversal gives back what was taken at the original rate
+	reversal, err := store.ReverseTransferTx(context.Background(), ReverseTransferParams{
+		TransferID: result.Transfer.ID,
+		ReversedBy: createRandomUser(t).Username,
+		Reason:     "wrong currency",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(16), reversal.Transfer.Amount)
+	require.Equal(t, int64(11), reversal.Transfer.ToAmount)
+	require.Equal(t, from.Balance, reversal.ToAccount.Balance)
+	require.Equal(t, to.Balance, reversal.FromAccount.Balance)
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+)
+
+// SystemPurposeFees is the purpose of the system account in each currency that
+// collects transfer fees
+const SystemPurposeFees = "fees"
+
+// scopes of a fee rule
+const (
+	FeeScopeOwn        = "own"
+	FeeScopeCrossOwner = "cross_owner"
+)
+
+// bpsPerUnit is the number of basis points in a whole
+const bpsPerUnit = 10000
+
+// Fee is what a transfer is charged on top of its amount, in the currency of the from
+// account. Flat and Percentage are the parts of the rule before Amount was held within
+// its min and max, RuleID is 0 when no rule applies
+type Fee struct {
+	RuleID     int64
+	Flat       int64
+	Percentage int64
+	Amount     int64
+}
+
+// feeScope return the scope of the fee rules of a transfer from from to to
+func feeScope(from Account, to Account) string {
+	if from.UserID == to.UserID {
+		return FeeScopeOwn
+	}
+	return FeeScopeCrossOwner
+}
+
+// apply return the fee of rule on amount, the percentage is rounded down
+func (rule FeeRule) apply(amount int64) Fee {
+	percentage := new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(rule.RateBps)))
+	percentage.Quo(percentage, big.NewInt(bpsPerUnit))
+
+	fee := Fee{RuleID: rule.ID, Flat: rule.FlatAmount, Percentage: percentage.Int64()}
+	fee.Amount = fee.Flat + fee.Percentage
+	if fee.Amount < rule.MinAmount {
+		fee.Amount = rule.MinAmount
+	}
+	if rule.MaxAmount.Valid && fee.Amount > rule.MaxAmount.Int64 {
+		fee.Amount = rule.MaxAmount.Int64
+	}
+	return fee
+}
+
+// quoteFee return the fee of sending amount from from to to, transfers without a rule
+// for their currency and scope are free
+func quoteFee(ctx context.Context, q *Queries, from Account, to Account, amount int64) (Fee, error) {
+	rule, err := q.GetFeeRule(ctx, GetFeeRuleParams{
+		Currency: from.Currency,
+		Scope:    feeScope(from, to),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Fee{}, nil
+		}
+		return Fee{}, err
+	}
+
+	return rule.apply(amount), nil
+}
+
+// QuoteFee return the fee TransferTx would charge for arg without transferring
+func (store *SQLStore) QuoteFee(ctx context.Context, arg TransferParams) (Fee, error) {
+	from, to, err := transferAccounts(ctx, store.Queries, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return Fee{}, err
+	}
+
+	return quoteFee(ctx, store.Queries, from, to, arg.Amount)
+}
+
+// postFee debit fee from the from account of a transfer to the fees account of its
+// currency, the entries join the journal of the transfer and the credit of the fees
+// account is deferred. It return the debit entry and the from account after it
+func postFee(ctx context.Context, q *Queries, from Account, fee Fee, transferID int64) (Entry, Account, error) {
+	fees, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+		Purpose:  SystemPurposeFees,
+		Currency: from.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Entry{}, from, fmt.Errorf("no %s account for currency %s", SystemPurposeFees, from.Currency)
+		}
+		return Entry{}, from, err
+	}
+
+	postings := []posting{
+		{accountID: from.ID, amount: -fee.Amount},
+		{accountID: fees.ID, amount: fee.Amount, deferred: true},
+	}
+
+	journal := sql.NullInt64{Int64: transferID, Valid: true}
+	var entry Entry
+	for i, p := range postings {
+		created, err := writePosting(ctx, q, p, journal, EntryTypeFee)
+		if err != nil {
+			return entry, from, err
+		}
+		if i == 0 {
+			entry = created
+		}
+	}
+
+	from, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: from.ID, Amount: -fee.Amount})
+	if err != nil {
+		return entry, from, ParseError(err)
+	}
+
+	return entry, from, nil
+}
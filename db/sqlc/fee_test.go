@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeRuleApply(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rule   FeeRule
+		amount int64
+		want   Fee
+	}{
+		{
+			name:   "Flat",
+			rule:   FeeRule{FlatAmount: 50},
+			amount: 10000,
+			want:   Fee{Flat: 50, Amount: 50},
+		},
+		{
+			name:   "PercentageRoundsDown",
+			rule:   FeeRule{FlatAmount: 10, RateBps: 150},
+			amount: 1999,
+			want:   Fee{Flat: 10, Percentage: 29, Amount: 39},
+		},
+		{
+			name:   "Min",
+			rule:   FeeRule{RateBps: 100, MinAmount: 25},
+			amount: 1000,
+			want:   Fee{Percentage: 10, Amount: 25},
+		},
+		{
+			name:   "Max",
+			rule:   FeeRule{RateBps: 100, MaxAmount: sql.NullInt64{Int64: 500, Valid: true}},
+			amount: 100000,
+			want:   Fee{Percentage: 1000, Amount: 500},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.rule.apply(tc.amount))
+		})
+	}
+}
+
+func TestTransferFee(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	for to.Currency != from.Currency {
+		to = createRandomAccount(t)
+	}
+
+	_, err := testDB.Exec(`INSERT INTO fee_rules (currency, scope, flat_amount, rate_bps) VALUES ($1, $2, 5, 100)`,
+		from.Currency, FeeScopeCrossOwner)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := testDB.Exec(`DELETE FROM fee_rules WHERE currency = $1 AND scope = $2`, from.Currency, FeeScopeCrossOwner)
+		require.NoError(t, err)
+	})
+
+	feesBefore, err := store.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemPurposeFees,
+		Currency: from.Currency,
+	})
+	require.NoError(t, err)
+
+	arg := TransferParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 100}
+	quote, err := store.QuoteFee(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), quote.Amount)
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, quote, result.Fee)
+	require.Equal(t, EntryTypeFee, result.FeeEntry.Type)
+	require.Equal(t, -quote.Amount, result.FeeEntry.Amount)
+	require.Equal(t, result.Transfer.ID, result.FeeEntry.TransferID.Int64)
+	require.Equal(t, from.Balance-arg.Amount-quote.Amount, result.FromAccount.Balance)
+	require.Equal(t, to.Balance+arg.Amount, result.ToAccount.Balance)
+
+	fees, err := store.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemPurposeFees,
+		Currency: from.Currency,
+	})
+	require.NoError(t, err)
+	require.False(t, fees.OverdraftLimit.Valid)
+
+	// the fees account is credited by the posting sweep
+	require.Equal(t, feesBefore.Balance, fees.Balance)
+
+	_, err = store.ApplyPendingPostings(context.Background())
+	require.NoError(t, err)
+
+	fees, err = store.GetAccount(context.Background(), fees.ID)
+	require.NoError(t, err)
+
+	var entriesTotal int64
+	err = testDB.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM entries WHERE account_id = $1`, fees.ID).Scan(&entriesTotal)
+	require.NoError(t, err)
+	require.Equal(t, entriesTotal, fees.Balance)
+}
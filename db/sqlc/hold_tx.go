@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// statuses of a hold, only pending holds reduce the available balance
+const (
+	HoldStatusPending  = "pending"
+	HoldStatusCaptured = "captured"
+	HoldStatusVoided   = "voided"
+	HoldStatusExpired  = "expired"
+)
+
+// DefaultHoldExpiryBatchSize is how many expired holds ExpireHoldsTx releases at most
+const DefaultHoldExpiryBatchSize = 100
+
+type AuthorizeParams struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	ExpiresAt     time.Time
+}
+
+// AuthorizeTx place a hold of amount on the from account for a later capture to the to
+// account, the hold reduces the available balance without moving funds. The transfer
+// limits and the fee are checked now and the fee is held with the amount, so the capture
+// can't fail on them. It fails with ErrInsufficientFunds when the available balance
+// doesn't cover the amount and its fee
+func (store *SQLStore) AuthorizeTx(ctx context.Context, arg AuthorizeParams) (Hold, error) {
+	var hold Hold
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+		from, to, err := transferAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if err := checkActive(from, to); err != nil {
+			return err
+		}
+
+		fee, err := transferFee(ctx, q, from, to, arg.Amount)
+		if err != nil {
+			return err
+		}
+		if err := checkBalance(ctx, q, arg.FromAccountID, arg.Amount+fee.Amount); err != nil {
+			return err
+		}
+
+		hold, err = q.CreateHold(ctx, CreateHoldParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			Currency:      from.Currency,
+			ExpiresAt:     arg.ExpiresAt,
+			FeeAmount:     fee.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.AddAccountHold(ctx, AddAccountHoldParams{ID: arg.FromAccountID, Amount: hold.reserved()})
+		return err
+	})
+
+	return hold, err
+}
+
+// reserved is what a pending hold takes off the available balance of its from account
+func (hold Hold) reserved() int64 {
+	return hold.Amount + hold.FeeAmount
+}
+
+type CaptureHoldParams struct {
+	HoldID int64
+	// Amount is the part of the hold to settle, 0 settles all of it
+	Amount int64
+}
+
+type CaptureHoldResult struct {
+	TransferResult
+	Hold Hold
+}
+
+// CaptureHoldTx settle a pending hold with a transfer of arg.Amount and release the rest
+// of it, a hold can be captured once and not after it expired. The transfer isn't checked
+// against the transfer limits again nor charged more than the fee held with it
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldParams) (CaptureHoldResult, error) {
+	var result CaptureHoldResult
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+		hold, err := pendingHold(ctx, q, arg.HoldID)
+		if err != nil {
+			return err
+		}
+		if !hold.ExpiresAt.After(time.Now()) {
+			return ErrHoldExpired
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+
+		// release the whole hold first so the transfer can use the funds it held
+		if _, err := q.AddAccountHold(ctx, AddAccountHoldParams{ID: hold.FromAccountID, Amount: -hold.reserved()}); err != nil {
+			return err
+		}
+
+		from, to, err := transferAccounts(ctx, q, hold.FromAccountID, hold.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if err := checkActive(from, to); err != nil {
+			return err
+		}
+
+		// the limits were checked by the authorization, the fee is quoted on the captured
+		// amount and never more than what was held for it
+		var fee Fee
+		if hold.FeeAmount > 0 {
+			fee, err = quoteFee(ctx, q, from, to, amount)
+			if err != nil {
+				return err
+			}
+			if fee.Amount > hold.FeeAmount {
+				fee.Amount = hold.FeeAmount
+			}
+		}
+
+		result.TransferResult, err = settleTransfer(ctx, q, TransferParams{
+			FromAccountID: hold.FromAccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        amount,
+		}, from, to, fee, EntryTypeTransfer)
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:             hold.ID,
+			Status:         HoldStatusCaptured,
+			CapturedAmount: amount,
+			TransferID:     sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// VoidHoldTx release a pending hold without moving funds
+func (store *SQLStore) VoidHoldTx(ctx context.Context, holdID int64) (Hold, error) {
+	var hold Hold
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+		var err error
+		hold, err = pendingHold(ctx, q, holdID)
+		if err != nil {
+			return err
+		}
+
+		hold, err = releaseHold(ctx, q, hold, HoldStatusVoided)
+		return err
+	})
+
+	return hold, err
+}
+
+// ExpireHoldsTx release up to batchSize pending holds that expired by now, skipping holds
+// locked by a capture or another sweep, and return them
+func (store *SQLStore) ExpireHoldsTx(ctx context.Context, now time.Time, batchSize int32) ([]Hold, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultHoldExpiryBatchSize
+	}
+
+	var expired []Hold
+	err := store.execTx(ctx, func(q *Queries) error {
+		holds, err := q.ListExpiredHolds(ctx, ListExpiredHoldsParams{Now: now, LimitCount: batchSize})
+		if err != nil {
+			return err
+		}
+
+		expired = make([]Hold, len(holds))
+		for i, hold := range holds {
+			expired[i], err = releaseHold(ctx, q, hold, HoldStatusExpired)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return expired, err
+}
+
+// pendingHold lock the hold with holdID, failing with ErrHoldNotPending once it was
+// captured, voided or expired
+func pendingHold(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+	if hold.Status != HoldStatusPending {
+		return hold, ErrHoldNotPending
+	}
+	return hold, nil
+}
+
+// releaseHold give the amount of a pending hold back to the available balance and close
+// it with status
+func releaseHold(ctx context.Context, q *Queries, hold Hold, status string) (Hold, error) {
+	if _, err := q.AddAccountHold(ctx, AddAccountHoldParams{ID: hold.FromAccountID, Amount: -hold.reserved()}); err != nil {
+		return hold, err
+	}
+
+	return q.UpdateHold(ctx, UpdateHoldParams{
+		ID:     hold.ID,
+		Status: status,
+	})
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func authorizeRandomHold(t *testing.T, store Store, from Account, to Account, amount int64, expiresAt time.Time) Hold {
+	hold, err := store.AuthorizeTx(context.Background(), AuthorizeParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusPending, hold.Status)
+	require.Equal(t, from.Currency, hold.Currency)
+	return hold
+}
+
+func TestAuthorizeTx(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	authorizeRandomHold(t, store, from, to, from.Balance, time.Now().Add(time.Hour))
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, account.Balance)
+	require.Equal(t, from.Balance, account.HoldAmount)
+
+	// the hold took the whole available balance
+	_, err = store.AuthorizeTx(context.Background(), AuthorizeParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        1,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.TransferTx(context.Background(), TransferParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 1})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	for to.Currency != from.Currency {
+		to = createRandomAccount(t)
+	}
+	hold := authorizeRandomHold(t, store, from, to, 100, time.Now().Add(time.Hour))
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldParams{HoldID: hold.ID, Amount: 101})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldParams{HoldID: hold.ID, Amount: 40})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, int64(40), result.Hold.CapturedAmount)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+
+	// the uncaptured 60 was released with the hold
+	require.Equal(t, from.Balance-40, result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.HoldAmount)
+	require.Equal(t, to.Balance+40, result.ToAccount.Balance)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotPending)
+}
+
+func TestVoidHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	hold := authorizeRandomHold(t, store, from, to, 100, time.Now().Add(time.Hour))
+
+	voided, err := store.VoidHoldTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusVoided, voided.Status)
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, account.Balance)
+	require.Zero(t, account.HoldAmount)
+
+	_, err = store.VoidHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotPending)
+}
+
+func TestExpireHoldsTx(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	hold := authorizeRandomHold(t, store, from, to, 100, time.Now().Add(time.Second))
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldParams{HoldID: hold.ID})
+	require.NoError(t, err)
+	hold = authorizeRandomHold(t, store, from, to, 100, time.Now().Add(time.Second))
+
+	// release every hold expired by then, including ones left by other tests
+	for {
+		expired, err := store.ExpireHoldsTx(context.Background(), time.Now().Add(time.Minute), DefaultHoldExpiryBatchSize)
+		require.NoError(t, err)
+		if len(expired) < DefaultHoldExpiryBatchSize {
+			break
+		}
+	}
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotPending)
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.HoldAmount)
+}
+
+func TestCaptureHoldWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	for to.Currency != from.Currency {
+		to = createRandomAccount(t)
+	}
+
+	_, err := testDB.Exec(`INSERT INTO fee_rules (currency, scope, flat_amount, rate_bps) VALUES ($1, $2, 5, 100)`,
+		from.Currency, FeeScopeCrossOwner)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := testDB.Exec(`DELETE FROM fee_rules WHERE currency = $1 AND scope = $2`, from.Currency, FeeScopeCrossOwner)
+		require.NoError(t, err)
+	})
+
+	// exactly the amount and its fee of 6
+	from, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: from.ID, Balance: 106})
+	require.NoError(t, err)
+
+	hold := authorizeRandomHold(t, store, from, to, 100, time.Now().Add(time.Hour))
+	require.Equal(t, int64(6), hold.FeeAmount)
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(106), account.HoldAmount)
+
+	// a dearer rule doesn't charge the capture more than was held
+	_, err = testDB.Exec(`UPDATE fee_rules SET flat_amount = 50 WHERE currency = $1 AND scope = $2`,
+		from.Currency, FeeScopeCrossOwner)
+	require.NoError(t, err)
+
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldParams{HoldID: hold.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), result.Hold.CapturedAmount)
+	require.Equal(t, hold.FeeAmount, result.Fee.Amount)
+	require.Zero(t, result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.HoldAmount)
+	require.Equal(t, to.Balance+100, result.ToAccount.Balance)
+}
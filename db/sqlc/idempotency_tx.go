@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+type IdempotentTransferParams struct {
+	TransferParams
+	Username    string
+	Key         string
+	RequestHash string
+}
+
+// IdempotentTransferTx run TransferTx and store its result under the idempotency key of
+// the user in the same transaction, so a transfer is never committed without its key
+func (store *SQLStore) IdempotentTransferTx(ctx context.Context, arg IdempotentTransferParams) (TransferResult, error) {
+	var result TransferResult
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+		var err error
+		result, err = transfer(ctx, q, arg.TransferParams, EntryTypeTransfer)
+		if err != nil {
+			return err
+		}
+
+		response, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+			Username:    arg.Username,
+			Key:         arg.Key,
+			RequestHash: arg.RequestHash,
+			Response:    response,
+		})
+		if errors.Is(ParseError(err), ErrUniqueViolation) {
+			return ErrIdempotencyKeyExists
+		}
+		return err
+	})
+
+	return result, err
+}
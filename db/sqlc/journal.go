@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TransferJournal is a transfer together with the entries it posted
+type TransferJournal struct {
+	Transfer Transfer `json:"transfer"`
+	Entries  []Entry  `json:"entries"`
+}
+
+// GetTransferJournal return the transfer with id and its entries
+func GetTransferJournal(ctx context.Context, q Querier, id int64) (TransferJournal, error) {
+	var journal TransferJournal
+
+	transfer, err := q.GetTransfer(ctx, id)
+	if err != nil {
+		return journal, err
+	}
+
+	entries, err := q.ListEntriesByTransfer(ctx, sql.NullInt64{Int64: id, Valid: true})
+	if err != nil {
+		return journal, err
+	}
+
+	journal.Transfer = transfer
+	journal.Entries = entries
+	return journal, nil
+}
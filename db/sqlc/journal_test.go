@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTransferJournal(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	journal, err := GetTransferJournal(context.Background(), store, result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, journal.Transfer.ID)
+	require.Len(t, journal.Entries, 2)
+	require.Equal(t, result.FromEntry.ID, journal.Entries[0].ID)
+	require.Equal(t, result.ToEntry.ID, journal.Entries[1].ID)
+
+	var total int64
+	for _, entry := range journal.Entries {
+		require.Equal(t, EntryTypeTransfer, entry.Type)
+		total += entry.Amount
+	}
+	require.Zero(t, total)
+}
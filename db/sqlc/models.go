@@ -3,7 +3,11 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
@@ -13,6 +17,28 @@ type Account struct {
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
 	UserID    int64     `json:"user_id"`
+	// the total of the pending holds on the account, the available balance is balance - hold_amount
+	HoldAmount int64 `json:"hold_amount"`
+	// active, frozen or closed, only active accounts send and receive money
+	Status string `json:"status"`
+	// why the account was last frozen, unfrozen or closed
+	StatusReason string `json:"status_reason"`
+	// when the status was last changed
+	StatusChangedAt time.Time `json:"status_changed_at"`
+	// when the account was closed, closing is final
+	ClosedAt sql.NullTime `json:"closed_at"`
+	// how far below zero the balance can go, null for the bank's own accounts which have no limit
+	OverdraftLimit sql.NullInt64 `json:"overdraft_limit"`
+}
+
+type Currency struct {
+	// ISO 4217 alphabetic code
+	Code string `json:"code"`
+	// ISO 4217 minor unit, amounts in this currency are in 10^-exponent of a unit
+	Exponent int32 `json:"exponent"`
+	// new accounts can only be opened in enabled currencies
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -21,15 +47,173 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// the transfer journal the entry belongs to, null for entries outside a transfer
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// transfer, deposit, withdrawal, fee, interest or reversal
+	Type string `json:"type"`
+}
+
+type ExchangeRate struct {
+	ID            int64  `json:"id"`
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	// the price of one unit of base_currency in quote_currency
+	Rate string `json:"rate"`
+	// the rate is in effect until a later valid_from of the same pair
+	ValidFrom time.Time `json:"valid_from"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeeRule struct {
+	ID int64 `json:"id"`
+	// the currency of the from accounts the rule applies to, fees are charged in it
+	Currency string `json:"currency"`
+	// own for transfers between accounts of one user, cross_owner for transfers to another user
+	Scope string `json:"scope"`
+	// charged on every transfer, in minor units of currency
+	FlatAmount int64 `json:"flat_amount"`
+	// the percentage of the amount charged on top of flat_amount, in hundredths of a percent
+	RateBps int32 `json:"rate_bps"`
+	// the smallest fee charged
+	MinAmount int64 `json:"min_amount"`
+	// the largest fee charged, null for no cap
+	MaxAmount sql.NullInt64 `json:"max_amount"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Hold struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// the authorized amount in currency, the currency of from_account_id
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// the part of amount settled by the capture, the rest was released
+	CapturedAmount int64 `json:"captured_amount"`
+	// pending, captured, voided or expired, only pending holds reduce the available balance
+	Status string `json:"status"`
+	// the transfer made by the capture
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	// the fee quoted when the hold was authorized, held on top of amount and the most its capture is charged
+	FeeAmount int64 `json:"fee_amount"`
+}
+
+type IdempotencyKey struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+	// sha256 of the request body the key was first used with
+	RequestHash string          `json:"request_hash"`
+	Response    json.RawMessage `json:"response"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type PendingPosting struct {
+	EntryID   int64 `json:"entry_id"`
+	AccountID int64 `json:"account_id"`
+	// the amount of the entry, added to the balance of the account by the posting sweep
+	Amount int64 `json:"amount"`
+}
+
+type RevokedToken struct {
+	// the payload id of the revoked access or refresh token
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// in currency, the currency of from_account_id
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// once, daily, weekly or monthly counted from starts_at
+	Frequency string    `json:"frequency"`
+	StartsAt  time.Time `json:"starts_at"`
+	// no run is scheduled after it, null repeats until cancelled
+	EndsAt    sql.NullTime `json:"ends_at"`
+	NextRunAt time.Time    `json:"next_run_at"`
+	// active, paused, completed or cancelled, only active schedules run
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64 `json:"id"`
+	ScheduledTransferID int64 `json:"scheduled_transfer_id"`
+	// the next_run_at the run was due at
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Status       string    `json:"status"`
+	// the transfer made by a succeeded run
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// why a failed run was rejected
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type SystemAccount struct {
+	// cash is the counterpart of deposits and withdrawals, fx of currency conversions
+	Purpose   string `json:"purpose"`
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
 }
 
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	// must be positive
+	// must be positive, in the currency of from_account_id
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// the amount credited in the currency of to_account_id
+	ToAmount int64 `json:"to_amount"`
+	// the rate amount was converted with, 1 between accounts of the same currency
+	ExchangeRate string `json:"exchange_rate"`
+	// a memo shown to both sides of the transfer
+	Description string `json:"description"`
+	// an identifier of the transfer in the systems of the client, not unique
+	Reference string `json:"reference"`
+	// a json object the client attached to the transfer, stored as is
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+type TransferLimit struct {
+	Tier     string `json:"tier"`
+	Currency string `json:"currency"`
+	// the largest single transfer, in minor units of currency
+	MaxAmount int64 `json:"max_amount"`
+	// the most a user can send from their accounts in currency in a UTC day
+	DailyAmount int64 `json:"daily_amount"`
+	// how many transfers a user can send from their accounts in currency in a UTC day
+	DailyCount int32     `json:"daily_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type TransferReversal struct {
+	// the reversed transfer, a transfer can be reversed once
+	TransferID int64 `json:"transfer_id"`
+	// the compensating transfer moving the amount back
+	ReversalTransferID int64     `json:"reversal_transfer_id"`
+	ReversedBy         string    `json:"reversed_by"`
+	Reason             string    `json:"reason"`
+	CreatedAt          time.Time `json:"created_at"`
 }
 
 type User struct {
@@ -41,4 +225,10 @@ type User struct {
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
+	// customer, teller, admin or auditor
+	Role string `json:"role"`
+	// standard or premium, picks the transfer limits of the user
+	Tier string `json:"tier"`
+	// every token of the user issued before this time is rejected, moved by password changes and session revocations
+	TokensRevokedAt time.Time `json:"tokens_revoked_at"`
 }
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type SetOverdraftLimitParams struct {
+	AccountID int64
+	// Limit is how far below zero the balance can go, 0 removes the overdraft
+	Limit int64
+}
+
+// SetOverdraftLimitTx change the overdraft limit of a customer account, failing with
+// ErrOverdraftInUse when the balance is already below the new limit
+func (store *SQLStore) SetOverdraftLimitTx(ctx context.Context, arg SetOverdraftLimitParams) (Account, error) {
+	var account Account
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrAccountNotFound
+			}
+			return err
+		}
+
+		// the bank's own accounts have no limit and must keep it that way
+		if !account.OverdraftLimit.Valid {
+			return fmt.Errorf("%w: account [%v] is a system account", ErrAccountNotFound, account.ID)
+		}
+		if account.Status == AccountStatusClosed {
+			return fmt.Errorf("%w: account [%v]", ErrAccountClosed, account.ID)
+		}
+		if account.Balance < -arg.Limit {
+			return ErrOverdraftInUse
+		}
+
+		account, err = q.UpdateAccountOverdraftLimit(ctx, UpdateAccountOverdraftLimitParams{
+			ID:             arg.AccountID,
+			OverdraftLimit: sql.NullInt64{Int64: arg.Limit, Valid: true},
+		})
+		return err
+	})
+
+	return account, err
+}
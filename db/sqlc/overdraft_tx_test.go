@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverdraftLimit(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	for to.Currency != from.Currency {
+		to = createRandomAccount(t)
+	}
+	require.True(t, from.OverdraftLimit.Valid)
+	require.Zero(t, from.OverdraftLimit.Int64)
+
+	from, err := store.SetOverdraftLimitTx(context.Background(), SetOverdraftLimitParams{AccountID: from.ID, Limit: 100})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), from.OverdraftLimit.Int64)
+
+	result, err := store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        from.Balance + 60,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-60), result.FromAccount.Balance)
+
+	_, err = store.TransferTx(context.Background(), TransferParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 41})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.SetOverdraftLimitTx(context.Background(), SetOverdraftLimitParams{AccountID: from.ID, Limit: 50})
+	require.ErrorIs(t, err, ErrOverdraftInUse)
+
+	// the database refuses a balance past the limit even without the balance check
+	_, err = testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: from.ID, Amount: -41})
+	require.ErrorIs(t, ParseError(err), ErrInsufficientFunds)
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultReconciliationBatchSize is how many accounts or transfers are read per query
+const DefaultReconciliationBatchSize = 500
+
+// kinds of ledger discrepancies
+const (
+	DiscrepancyBalanceMismatch  = "balance_mismatch"
+	DiscrepancyUnmatchedEntries = "unmatched_transfer_entries"
+)
+
+// Discrepancy is a single inconsistency found by ReconcileLedger
+type Discrepancy struct {
+	Kind       string `json:"kind"`
+	AccountID  int64  `json:"account_id,omitempty"`
+	TransferID int64  `json:"transfer_id,omitempty"`
+	Expected   int64  `json:"expected"`
+	Actual     int64  `json:"actual"`
+	Detail     string `json:"detail"`
+}
+
+type ReconciliationReport struct {
+	StartedAt        time.Time     `json:"started_at"`
+	FinishedAt       time.Time     `json:"finished_at"`
+	AccountsChecked  int           `json:"accounts_checked"`
+	TransfersChecked int           `json:"transfers_checked"`
+	Discrepancies    []Discrepancy `json:"discrepancies"`
+}
+
+// ReconcileLedger verify that every account balance equals the sum of its entries less
+// the pending postings and that every transfer has exactly one matching entry on each
+// side besides its fee entries, reading accounts and transfers in batches of batchSize
+// ordered by id
+func ReconcileLedger(ctx context.Context, q Querier, batchSize int32) (ReconciliationReport, error) {
+	report := ReconciliationReport{
+		StartedAt:     time.Now(),
+		Discrepancies: []Discrepancy{},
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultReconciliationBatchSize
+	}
+
+	var afterID int64
+	for {
+		accounts, err := q.ListAccountLedgerTotals(ctx, ListAccountLedgerTotalsParams{
+			AfterID:    afterID,
+			LimitCount: batchSize,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		for _, account := range accounts {
+			// pending postings are entries not yet added to the balance
+			expected := account.EntriesTotal - account.PendingTotal
+			if account.Balance != expected {
+				report.Discrepancies = append(report.Discrepancies, Discrepancy{
+					Kind:      DiscrepancyBalanceMismatch,
+					AccountID: account.ID,
+					Expected:  expected,
+					Actual:    account.Balance,
+					Detail:    fmt.Sprintf("balance %d differs from entries total %d less pending %d by %d", account.Balance, account.EntriesTotal, account.PendingTotal, account.Balance-expected),
+				})
+			}
+			afterID = account.ID
+		}
+		report.AccountsChecked += len(accounts)
+
+		if len(accounts) < int(batchSize) {
+			break
+		}
+	}
+
+	afterID = 0
+	for {
+		transfers, err := q.ListTransferEntryCounts(ctx, ListTransferEntryCountsParams{
+			AfterID:    afterID,
+			LimitCount: batchSize,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		for _, transfer := range transfers {
+			if transfer.FromEntries != 1 || transfer.ToEntries != 1 {
+				report.Discrepancies = append(report.Discrepancies, Discrepancy{
+					Kind:       DiscrepancyUnmatchedEntries,
+					TransferID: transfer.ID,
+					Expected:   2,
+					Actual:     transfer.FromEntries + transfer.ToEntries,
+					Detail: fmt.Sprintf("transfer of %d from account %d to %d has %d debit and %d credit entries",
+						transfer.Amount, transfer.FromAccountID, transfer.ToAccountID, transfer.FromEntries, transfer.ToEntries),
+				})
+			}
+			afterID = transfer.ID
+		}
+		report.TransfersChecked += len(transfers)
+
+		if len(transfers) < int(batchSize) {
+			break
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileLedger(t *testing.T) {
+	store := NewStore(testDB)
+
+	// accounts created directly with a balance have no entries backing it
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+
+	report, err := ReconcileLedger(context.Background(), store, 2)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, report.AccountsChecked, 2)
+	require.GreaterOrEqual(t, report.TransfersChecked, 1)
+	require.False(t, report.FinishedAt.Before(report.StartedAt))
+
+	var mismatch *Discrepancy
+	for i, discrepancy := range report.Discrepancies {
+		if discrepancy.TransferID == result.Transfer.ID {
+			t.Fatalf("transfer %d reported as %s", result.Transfer.ID, discrepancy.Kind)
+		}
+		if discrepancy.Kind == DiscrepancyBalanceMismatch && discrepancy.AccountID == account1.ID {
+			mismatch = &report.Discrepancies[i]
+		}
+	}
+
+	require.NotNil(t, mismatch)
+	require.Equal(t, int64(-1), mismatch.Expected)
+	require.Equal(t, account1.Balance-1, mismatch.Actual)
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type ReverseTransferParams struct {
+	TransferID int64
+	ReversedBy string
+	Reason     string
+}
+
+type ReverseTransferResult struct {
+	TransferResult
+	Reversal TransferReversal
+}
+
+// ReverseTransferTx move the amount of a transfer back with a linked reversal transfer
+// and compensating entries at the rate of the original, a transfer can be reversed
+// once and only while the receiving account still holds the amount and both accounts
+// are active. A reversal can't itself be reversed and the fee charged on the original
+// isn't refunded
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferParams) (ReverseTransferResult, error) {
+	var result ReverseTransferResult
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+		original, err := q.GetTransfer(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.GetTransferReversal(ctx, original.ID)
+		if err == nil {
+			return ErrAlreadyReversed
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		_, err = q.GetTransferReversalByReversalTransfer(ctx, original.ID)
+		if err == nil {
+			return ErrReversalNotReversible
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		// the reversal runs from the receiver of the original back to its sender
+		from, to, err := transferAccounts(ctx, q, original.ToAccountID, original.FromAccountID)
+		if err != nil {
+			return err
+		}
+		if err := checkActive(from, to); err != nil {
+			return err
+		}
+
+		reversal, conv, err := reverseConversion(ctx, q, original, from, to)
+		if err != nil {
+			return err
+		}
+		if err := checkBalance(ctx, q, reversal.FromAccountID, reversal.Amount); err != nil {
+			return err
+		}
+
+		result.TransferResult, err = postTransfer(ctx, q, reversal, conv, EntryTypeReversal)
+		if err != nil {
+			return err
+		}
+
+		result.Reversal, err = q.CreateTransferReversal(ctx, CreateTransferReversalParams{
+			TransferID:         original.ID,
+			ReversalTransferID: result.Transfer.ID,
+			ReversedBy:         arg.ReversedBy,
+			Reason:             arg.Reason,
+		})
+		if errors.Is(ParseError(err), ErrUniqueViolation) {
+			// reversed concurrently by another request
+			return ErrAlreadyReversed
+		}
+		return err
+	})
+
+	return result, err
+}
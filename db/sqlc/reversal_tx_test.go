@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	staff := createRandomUser(t)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	original, err := store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	arg := ReverseTransferParams{
+		TransferID: original.Transfer.ID,
+		ReversedBy: staff.Username,
+		Reason:     "sent to the wrong account",
+	}
+	result, err := store.ReverseTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, account2.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, result.Transfer.ToAccountID)
+	require.Equal(t, EntryTypeReversal, result.FromEntry.Type)
+	require.Equal(t, EntryTypeReversal, result.ToEntry.Type)
+	require.Equal(t, account1.Balance, result.ToAccount.Balance)
+	require.Equal(t, account2.Balance, result.FromAccount.Balance)
+
+	require.Equal(t, original.Transfer.ID, result.Reversal.TransferID)
+	require.Equal(t, result.Transfer.ID, result.Reversal.ReversalTransferID)
+	require.Equal(t, staff.Username, result.Reversal.ReversedBy)
+	require.Equal(t, arg.Reason, result.Reversal.Reason)
+
+	_, err = store.ReverseTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAlreadyReversed)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferParams{
+		TransferID: result.Transfer.ID,
+		ReversedBy: staff.Username,
+		Reason:     "reversed by mistake",
+	})
+	require.ErrorIs(t, err, ErrReversalNotReversible)
+}
+
+func TestReverseTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	staff := createRandomUser(t)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	original, err := store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusParams{
+		AccountID: account1.ID,
+		Status:    AccountStatusFrozen,
+		Reason:    "under investigation",
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferParams{
+		TransferID: original.Transfer.ID,
+		ReversedBy: staff.Username,
+		Reason:     "sent to the wrong account",
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	_, err = store.GetTransferReversal(context.Background(), original.Transfer.ID)
+	require.Error(t, err)
+}
+
+func TestReverseTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	staff := createRandomUser(t)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+
+	original, err := store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// the receiver spends everything before the reversal
+	_, err = store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account3.ID,
+		Amount:        original.ToAccount.Balance,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferParams{
+		TransferID: original.Transfer.ID,
+		ReversedBy: staff.Username,
+		Reason:     "sent to the wrong account",
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.GetTransferReversal(context.Background(), original.Transfer.ID)
+	require.Error(t, err)
+}
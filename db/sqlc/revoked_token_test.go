@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeToken(t *testing.T) {
+	user := createRandomUser(t)
+	id := uuid.New()
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), id)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	args := RevokeTokenParams{
+		ID:        id,
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	err = testQueries.RevokeToken(context.Background(), args)
+	require.NoError(t, err)
+
+	// revoking twice is a no-op
+	err = testQueries.RevokeToken(context.Background(), args)
+	require.NoError(t, err)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), id)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevokeUserSessionsTx(t *testing.T) {
+	testStore := NewStore(testDB)
+	session1 := createRandomSession(t)
+
+	result, err := testStore.RevokeUserSessionsTx(context.Background(), session1.Username)
+	require.NoError(t, err)
+	require.Len(t, result.Sessions, 1)
+	require.Equal(t, session1.ID, result.Sessions[0].ID)
+	require.True(t, result.Sessions[0].IsBlocked)
+
+	revoked, err := testStore.IsTokenRevoked(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// the access tokens of the user are revoked with the sessions
+	revokedAt, err := testStore.GetUserTokensRevokedAt(context.Background(), session1.Username)
+	require.NoError(t, err)
+	require.True(t, revokedAt.Equal(result.RevokedAt))
+
+	// already blocked sessions are not returned again
+	result, err = testStore.RevokeUserSessionsTx(context.Background(), session1.Username)
+	require.NoError(t, err)
+	require.Empty(t, result.Sessions)
+	require.False(t, result.RevokedAt.Before(revokedAt))
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextScheduledRun(t *testing.T) {
+	start := time.Date(2022, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		frequency string
+		after     time.Time
+		want      time.Time
+		ok        bool
+	}{
+		{name: "NotStarted", frequency: FrequencyDaily, after: start.Add(-time.Hour), want: start, ok: true},
+		{name: "OnceDone", frequency: FrequencyOnce, after: start},
+		{name: "Daily", frequency: FrequencyDaily, after: start, want: start.AddDate(0, 0, 1), ok: true},
+		{name: "DailySkipsMissed", frequency: FrequencyDaily, after: start.AddDate(0, 0, 10).Add(time.Hour), want: start.AddDate(0, 0, 11), ok: true},
+		{name: "Weekly", frequency: FrequencyWeekly, after: start.AddDate(0, 0, 8), want: start.AddDate(0, 0, 14), ok: true},
+		{name: "MonthlyClampsToMonthEnd", frequency: FrequencyMonthly, after: start, want: time.Date(2022, time.February, 28, 9, 0, 0, 0, time.UTC), ok: true},
+		{name: "MonthlyKeepsDay", frequency: FrequencyMonthly, after: time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC), want: time.Date(2022, time.March, 31, 9, 0, 0, 0, time.UTC), ok: true},
+		{name: "Unknown", frequency: "hourly", after: start},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := NextScheduledRun(tc.frequency, start, tc.after)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.True(t, tc.want.Equal(got), "want %s got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func createDueScheduledTransfer(t *testing.T, from Account, to Account, amount int64, frequency string) ScheduledTransfer {
+	user := createRandomUser(t)
+	due := time.Now().Add(-time.Minute)
+
+	schedule, err := testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		Owner:         user.Username,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Currency:      from.Currency,
+		Frequency:     frequency,
+		StartsAt:      due,
+		NextRunAt:     due,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduleStatusActive, schedule.Status)
+	return schedule
+}
+
+// runAllDueScheduledTransfers run every due schedule, including ones left by other tests
+func runAllDueScheduledTransfers(t *testing.T, store Store) {
+	for {
+		_, err := store.RunScheduledTransferTx(context.Background(), time.Now())
+		if err == ErrNoScheduledTransferDue {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestRunScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	schedule := createDueScheduledTransfer(t, from, to, 10, FrequencyDaily)
+
+	runAllDueScheduledTransfers(t, store)
+
+	updated, err := store.GetScheduledTransfer(context.Background(), schedule.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduleStatusActive, updated.Status)
+	require.True(t, updated.NextRunAt.After(time.Now()))
+
+	runs, err := store.ListScheduledTransferRuns(context.Background(), ListScheduledTransferRunsParams{
+		ScheduledTransferID: schedule.ID,
+		Limit:               10,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, RunStatusSucceeded, runs[0].Status)
+	require.True(t, runs[0].TransferID.Valid)
+
+	transfer, err := store.GetTransfer(context.Background(), runs[0].TransferID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, schedule.Amount, transfer.Amount)
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance-schedule.Amount, account.Balance)
+}
+
+func TestRunScheduledTransferTxRejected(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	schedule := createDueScheduledTransfer(t, from, to, from.Balance+1, FrequencyOnce)
+
+	runAllDueScheduledTransfers(t, store)
+
+	updated, err := store.GetScheduledTransfer(context.Background(), schedule.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduleStatusCompleted, updated.Status)
+
+	runs, err := store.ListScheduledTransferRuns(context.Background(), ListScheduledTransferRunsParams{
+		ScheduledTransferID: schedule.ID,
+		Limit:               10,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, RunStatusFailed, runs[0].Status)
+	require.False(t, runs[0].TransferID.Valid)
+	require.Equal(t, ErrInsufficientFunds.Error(), runs[0].Error)
+}
+
+func TestUpdateOpenScheduledTransfer(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	schedule := createDueScheduledTransfer(t, from, to, 10, FrequencyOnce)
+	seen, err := store.GetScheduledTransfer(context.Background(), schedule.ID)
+	require.NoError(t, err)
+
+	// the scheduler completes the schedule after the owner read it
+	runAllDueScheduledTransfers(t, store)
+
+	_, err = store.UpdateOpenScheduledTransfer(context.Background(), UpdateOpenScheduledTransferParams{
+		ID:            seen.ID,
+		Amount:        seen.Amount,
+		EndsAt:        seen.EndsAt,
+		NextRunAt:     seen.NextRunAt,
+		Status:        ScheduleStatusPaused,
+		SeenStatus:    seen.Status,
+		SeenNextRunAt: seen.NextRunAt,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	updated, err := store.GetScheduledTransfer(context.Background(), schedule.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduleStatusCompleted, updated.Status)
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// how often a scheduled transfer repeats, recorded on scheduled_transfers.frequency
+const (
+	FrequencyOnce    = "once"
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+// statuses of a scheduled transfer, only active schedules are run
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusPaused    = "paused"
+	ScheduleStatusCompleted = "completed"
+	ScheduleStatusCancelled = "cancelled"
+)
+
+// outcomes of a scheduled transfer run
+const (
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// runErrorInternal is the error of a run that failed for something else than the state
+// of its accounts, the cause is returned to the scheduler rather than shown to the owner
+const runErrorInternal = "the transfer failed for an internal error"
+
+type ScheduledTransferRunResult struct {
+	Schedule ScheduledTransfer
+	Run      ScheduledTransferRun
+	// Cause is what a run recorded with runErrorInternal failed on
+	Cause error
+}
+
+// RunScheduledTransferTx claim the active schedule that has been due the longest at now,
+// skipping schedules claimed by other schedulers, make its transfer and move it to its
+// next run. The transfer is made by the steps of TransferTx in the claiming transaction
+// so a run and its transfer commit together, a transfer rejected for the state of the
+// accounts is recorded as a failed run. A transfer that fails for any other reason is
+// also recorded as a failed run, in a transaction of its own, so a broken schedule
+// doesn't stay the longest due and hold up the others. It fail with
+// ErrNoScheduledTransferDue when nothing is due
+func (store *SQLStore) RunScheduledTransferTx(ctx context.Context, now time.Time) (ScheduledTransferRunResult, error) {
+	var result ScheduledTransferRunResult
+	var schedule ScheduledTransfer
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
+		var err error
+		schedule, err = q.ClaimDueScheduledTransfer(ctx, now)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoScheduledTransferDue
+			}
+			return err
+		}
+
+		run := CreateScheduledTransferRunParams{
+			ScheduledTransferID: schedule.ID,
+			ScheduledFor:        schedule.NextRunAt,
+			Status:              RunStatusSucceeded,
+		}
+		transferred, err := transfer(ctx, q, TransferParams{
+			FromAccountID: schedule.FromAccountID,
+			ToAccountID:   schedule.ToAccountID,
+			Amount:        schedule.Amount,
+		}, EntryTypeTransfer)
+		switch {
+		case err == nil:
+			run.TransferID = sql.NullInt64{Int64: transferred.Transfer.ID, Valid: true}
+		case transferRejected(err):
+			run.Status = RunStatusFailed
+			run.Error = err.Error()
+		default:
+			return err
+		}
+
+		result, err = recordScheduledRun(ctx, q, schedule, run, now)
+		return err
+	})
+	if err == nil || err == ErrNoScheduledTransferDue || schedule.ID == 0 || ctx.Err() != nil {
+		return result, err
+	}
+
+	cause := err
+	err = store.execTx(ctx, func(q *Queries) error {
+		// another scheduler may have run the schedule since it was claimed
+		locked, err := q.GetScheduledTransferForUpdate(ctx, schedule.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != ScheduleStatusActive || !locked.NextRunAt.Equal(schedule.NextRunAt) {
+			result = ScheduledTransferRunResult{Schedule: locked}
+			return nil
+		}
+
+		result, err = recordScheduledRun(ctx, q, locked, CreateScheduledTransferRunParams{
+			ScheduledTransferID: locked.ID,
+			ScheduledFor:        locked.NextRunAt,
+			Status:              RunStatusFailed,
+			Error:               runErrorInternal,
+		}, now)
+		return err
+	})
+	result.Cause = cause
+
+	return result, err
+}
+
+// recordScheduledRun create run and move schedule to its next run after now, completing
+// it when it has none left
+func recordScheduledRun(ctx context.Context, q *Queries, schedule ScheduledTransfer, run CreateScheduledTransferRunParams, now time.Time) (ScheduledTransferRunResult, error) {
+	var result ScheduledTransferRunResult
+	var err error
+	result.Run, err = q.CreateScheduledTransferRun(ctx, run)
+	if err != nil {
+		return result, err
+	}
+
+	// runs missed while no scheduler was up are skipped rather than made late
+	after := schedule.NextRunAt
+	if now.After(after) {
+		after = now
+	}
+	update := UpdateScheduledTransferParams{
+		ID:        schedule.ID,
+		Amount:    schedule.Amount,
+		EndsAt:    schedule.EndsAt,
+		NextRunAt: schedule.NextRunAt,
+		Status:    ScheduleStatusCompleted,
+	}
+	if next, ok := NextScheduledRun(schedule.Frequency, schedule.StartsAt, after); ok &&
+		(!schedule.EndsAt.Valid || !next.After(schedule.EndsAt.Time)) {
+		update.NextRunAt = next
+		update.Status = ScheduleStatusActive
+	}
+
+	result.Schedule, err = q.UpdateScheduledTransfer(ctx, update)
+	return result, err
+}
+
+// transferRejected report whether err is a transfer refused for the state of its
+// accounts rather than a failure of the database
+func transferRejected(err error) bool {
+	for _, rejection := range []error{
+		ErrAccountNotFound, ErrAccountFrozen, ErrAccountClosed, ErrInsufficientFunds,
+		ErrTransferLimitExceeded, ErrCurrencyMismatch, ErrNoExchangeRate, ErrAmountTooSmall,
+	} {
+		if errors.Is(err, rejection) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextScheduledRun return the first run of a schedule of frequency starting at startsAt
+// that is strictly after after, it return false when a one off schedule has no run
+// left. Monthly runs fall on the day of month of startsAt, or the last day of shorter
+// months
+func NextScheduledRun(frequency string, startsAt time.Time, after time.Time) (time.Time, bool) {
+	if startsAt.After(after) {
+		return startsAt, true
+	}
+
+	var occurrence func(n int) time.Time
+	var period time.Duration
+	switch frequency {
+	case FrequencyDaily:
+		occurrence = func(n int) time.Time { return startsAt.AddDate(0, 0, n) }
+		period = 24 * time.Hour
+	case FrequencyWeekly:
+		occurrence = func(n int) time.Time { return startsAt.AddDate(0, 0, 7*n) }
+		period = 7 * 24 * time.Hour
+	case FrequencyMonthly:
+		occurrence = func(n int) time.Time { return addMonths(startsAt, n) }
+		period = 31 * 24 * time.Hour
+	default:
+		return time.Time{}, false
+	}
+
+	// start from an estimate that can only be early and step to the first later run
+	n := int(after.Sub(startsAt) / period)
+	next := occurrence(n)
+	for !next.After(after) {
+		n++
+		next = occurrence(n)
+	}
+	return next, true
+}
+
+// addMonths add months to t keeping its day of month, clamped to the last day of
+// shorter months
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// RevokeUserSessionsResult is the result of revoking the sessions of a user
+type RevokeUserSessionsResult struct {
+	Sessions []Session `json:"sessions"`
+	// tokens of the user issued before RevokedAt are no longer valid
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+// RevokeUserSessionsTx block every active session of username, record each
+// session's refresh token id as revoked and move the user's tokens_revoked_at
+// so the access tokens already issued are rejected as well
+func (store *SQLStore) RevokeUserSessionsTx(ctx context.Context, username string) (RevokeUserSessionsResult, error) {
+	var result RevokeUserSessionsResult
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Sessions, err = q.BlockUserSessions(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		for _, session := range result.Sessions {
+			err = q.RevokeToken(ctx, RevokeTokenParams{
+				ID:        session.ID,
+				Username:  session.Username,
+				ExpiresAt: session.ExpiresAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		result.RevokedAt, err = q.RevokeUserTokens(ctx, username)
+		return err
+	})
+
+	return result, err
+}
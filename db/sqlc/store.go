@@ -3,62 +3,73 @@ package db
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferParams) (TransferResult, error)
-	TransferTxPure(ctx context.Context, args TransferParams) (TransferResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferParams) (BatchTransferResult, error)
+	IdempotentTransferTx(ctx context.Context, arg IdempotentTransferParams) (TransferResult, error)
+	RevokeUserSessionsTx(ctx context.Context, username string) (RevokeUserSessionsResult, error)
+	DepositTx(ctx context.Context, arg CashParams) (CashResult, error)
+	WithdrawTx(ctx context.Context, arg CashParams) (CashResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferParams) (ReverseTransferResult, error)
+	CreateExchangeRatesTx(ctx context.Context, args []CreateExchangeRateParams) ([]ExchangeRate, error)
+	RunScheduledTransferTx(ctx context.Context, now time.Time) (ScheduledTransferRunResult, error)
+	AuthorizeTx(ctx context.Context, arg AuthorizeParams) (Hold, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldParams) (CaptureHoldResult, error)
+	VoidHoldTx(ctx context.Context, holdID int64) (Hold, error)
+	ExpireHoldsTx(ctx context.Context, now time.Time, batchSize int32) ([]Hold, error)
+	ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusParams) (Account, error)
+	SetOverdraftLimitTx(ctx context.Context, arg SetOverdraftLimitParams) (Account, error)
+	TransferAllowances(ctx context.Context, user User, now time.Time) ([]TransferAllowance, error)
+	QuoteFee(ctx context.Context, arg TransferParams) (Fee, error)
 }
 
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	db    *sql.DB
+	retry retryPolicy
 }
 
 func NewStore(db *sql.DB) Store {
 	return &SQLStore{
 		db:      db,
 		Queries: New(db),
+		retry:   defaultRetryPolicy,
 	}
 }
 
-// extend Store functionality to execute transactions
-func (s *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := s.db.BeginTx(ctx, nil)
-	defer tx.Rollback()
-	if err != nil {
-		return err
-	}
-
-	q := New(tx)
-	err = fn(q)
-
-	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("transaction error: %v, rollback Error: %v", err, rbErr)
-		}
-		return err
-	}
-
-	return tx.Commit()
-}
-
 type TransferResult struct {
 	FromAccount Account
 	ToAccount   Account
 	Transfer    Transfer
 	FromEntry   Entry
 	ToEntry     Entry
+	// Fee is what the from account was charged on top of the amount, FeeEntry is its
+	// debit, both are zero for free transfers
+	Fee      Fee
+	FeeEntry Entry
 }
 
 type TransferParams struct {
 	FromAccountID int64
 	ToAccountID   int64
 	Amount        int64
+	// Description, Reference and Metadata are stored on the transfer as given, an
+	// empty Metadata is stored as an empty object
+	Description string
+	Reference   string
+	Metadata    json.RawMessage
 }
 
+// emptyMetadata is the metadata of transfers the client attached none to
+var emptyMetadata = json.RawMessage(`{}`)
+
 // Transfer amount transaction from AccountA to AccountB using
 // 1- check if AccountA has enough balance (AccountA.amount >= amount)
 // 2- create transfer record to AccountB with amount amount
@@ -66,161 +77,207 @@ type TransferParams struct {
 // 4- create Entry record on AccountB with +amount
 // 5- subtract the amount from the AccountA (AccountA.balance - amount)
 // 6- add the amount to the AccountB (AccountB.balance + amount)
+// between accounts of different currencies AccountB is credited with amount converted
+// at the exchange rate in effect, the fx house accounts take the other side
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferParams) (TransferResult, error) {
 	var result TransferResult
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTxOptions(ctx, serializable, func(q *Queries) error {
 		var err error
-		// 1- check enough balance
-		enoughParam := EnoughAccountBalanceParams{
-			Balance: arg.Amount,
-			ID:      arg.FromAccountID,
-		}
-
-		// func (q *Queries) EnoughAccountBalance(ctx context.Context, arg EnoughAccountBalanceParams) (bool, error) {
-		enoughBalance, err := q.EnoughAccountBalance(ctx, enoughParam)
-		if err != nil {
-			return err
-		}
-		if !enoughBalance {
-			return fmt.Errorf("not enough balance")
-		}
+		result, err = transfer(ctx, q, arg, EntryTypeTransfer)
+		return err
+	})
 
-		// 2- Create transfer record
-		tsfrParams := CreateTransferParams(arg)
-		result.Transfer, err = q.CreateTransfer(ctx, tsfrParams)
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		// 3- Create FromAccount entry record
-		fromEntryParam := CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		}
-		result.FromEntry, err = q.CreateEntry(ctx, fromEntryParam)
-		if err != nil {
-			return err
-		}
+// transfer run the steps of TransferTx with q, which must be bound to a transaction,
+// posting the entries with entryType, it fails when either account isn't active. Transfers
+// between customers also fail past the transfer limits of the sender and are charged the
+// fee of their rule
+func transfer(ctx context.Context, q *Queries, arg TransferParams, entryType string) (TransferResult, error) {
+	from, to, err := transferAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return TransferResult{}, err
+	}
+	if err := checkActive(from, to); err != nil {
+		return TransferResult{}, err
+	}
 
-		// 4- Create toAccount entry record
-		toEntryParam := CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		}
-		result.ToEntry, err = q.CreateEntry(ctx, toEntryParam)
+	// deposits, withdrawals and the bank's own movements aren't capped nor charged
+	var fee Fee
+	if entryType == EntryTypeTransfer {
+		fee, err = transferFee(ctx, q, from, to, arg.Amount)
 		if err != nil {
-			return err
+			return TransferResult{}, err
 		}
+	}
 
-		if arg.FromAccountID > arg.ToAccountID {
-			// 5- Subtract amount from fromAccount balance
-			result.FromAccount, err = addMoney(q, ctx, arg.FromAccountID, -arg.Amount)
-			if err != nil {
-				return err
-			}
-			// 6- Add amount to toAccount balance
-			result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: arg.ToAccountID, Amount: arg.Amount})
-			if err != nil {
-				return err
-			}
-		} else {
-			// 6- Add amount to toAccount balance
-			result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: arg.ToAccountID, Amount: arg.Amount})
-			if err != nil {
-				return err
-			}
-			// 5- Subtract amount from fromAccount balance
-			result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: arg.FromAccountID, Amount: -arg.Amount})
-			if err != nil {
-				return err
-			}
-		}
+	return settleTransfer(ctx, q, arg, from, to, fee, entryType)
+}
 
-		return err
-	})
+// transferFee check a customer transfer of amount from from to to against the transfer
+// limits of the sender and quote its fee
+func transferFee(ctx context.Context, q *Queries, from Account, to Account, amount int64) (Fee, error) {
+	if err := checkTransferLimit(ctx, q, from, amount); err != nil {
+		return Fee{}, err
+	}
 
-	return result, err
+	fee, err := quoteFee(ctx, q, from, to, amount)
+	if err != nil {
+		return Fee{}, err
+	}
+	if fee.Amount > math.MaxInt64-amount {
+		return Fee{}, ErrInsufficientFunds
+	}
+	return fee, nil
 }
 
-func addMoney(q *Queries, ctx context.Context, accountId int64, amount int64) (Account, error) {
-	return q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: accountId, Amount: amount})
-}
+// settleTransfer run the steps of TransferTx once the accounts of arg are checked,
+// charging fee to the from account on top of the amount
+func settleTransfer(ctx context.Context, q *Queries, arg TransferParams, from Account, to Account, fee Fee, entryType string) (TransferResult, error) {
+	// 1- check enough balance for the amount and its fee
+	if err := checkBalance(ctx, q, arg.FromAccountID, arg.Amount+fee.Amount); err != nil {
+		return TransferResult{}, err
+	}
 
-func (store *SQLStore) TransferTxPure(ctx context.Context, args TransferParams) (TransferResult, error) {
+	conv, err := convert(ctx, q, from, to, arg.Amount)
+	if err != nil {
+		return TransferResult{}, err
+	}
 
-	var result TransferResult
-	// Create a helper function for preparing failure results.
-	fail := func(err error) (TransferResult, error) {
-		return result, fmt.Errorf("TransferTxPure: %v", err)
+	result, err := postTransfer(ctx, q, arg, conv, entryType)
+	if err != nil || fee.Amount == 0 {
+		return result, err
 	}
 
-	// Get a Tx for making transaction requests.
-	tx, err := store.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fail(err)
+	result.Fee = fee
+	result.FeeEntry, result.FromAccount, err = postFee(ctx, q, result.FromAccount, fee, result.Transfer.ID)
+	return result, err
+}
+
+// checkBalance fail with ErrInsufficientFunds unless the available balance of accountID
+// and its overdraft limit cover amount
+func checkBalance(ctx context.Context, q *Queries, accountID int64, amount int64) error {
+	enoughParam := EnoughAccountBalanceParams{
+		Balance: amount,
+		ID:      accountID,
 	}
-	// Defer a rollback in case anything fails.
-	defer tx.Rollback()
 
-	// 1- check if AccountA has enough balance (AccountA.amount >= amount)
-	var enough bool
-	if err = tx.QueryRowContext(ctx, "SELECT (balance >= $1) from accounts where id = $2",
-		args.Amount, args.FromAccountID).Scan(&enough); err != nil {
+	enoughBalance, err := q.EnoughAccountBalance(ctx, enoughParam)
+	if err != nil {
 		if err == sql.ErrNoRows {
-			return fail(fmt.Errorf("invalid account"))
+			return ErrAccountNotFound
 		}
-		return fail(err)
+		return err
 	}
-	if !enough {
-		return fail(fmt.Errorf("not enough balance"))
+	if !enoughBalance {
+		return ErrInsufficientFunds
 	}
+	return nil
+}
 
-	// 2- create transfer record to AccountB with amount amount
-	_, err = tx.ExecContext(ctx, "INSERT INTO transfers (from_account_id, to_account_id, amount) VALUES ($1, $2, $3)",
-		args.FromAccountID, args.ToAccountID, args.Amount)
-	if err != nil {
-		return fail(err)
-	}
+// postTransfer run steps 2 to 6 of TransferTx without checking the balance of the
+// from account, which lets the bank's own accounts go negative. The cash account's side
+// of a deposit or withdrawal is deferred like the fx house accounts', every teller
+// transaction in a currency would update its row otherwise
+func postTransfer(ctx context.Context, q *Queries, arg TransferParams, conv conversion, entryType string) (TransferResult, error) {
+	var result TransferResult
+	var err error
 
-	// 3- create Entry record on AccountA with -amount ie: negative amount
-	_, err = tx.ExecContext(ctx, "INSERT INTO entries (account_id, amount) VALUES ($1, $2)",
-		args.FromAccountID, -args.Amount)
-	if err != nil {
-		return fail(err)
+	// 2- Create transfer record
+	metadata := arg.Metadata
+	if len(metadata) == 0 {
+		metadata = emptyMetadata
 	}
-
-	// 4- create Entry record on AccountB with +amount
-	_, err = tx.ExecContext(ctx, "INSERT INTO entries (account_id, amount) VALUES ($1, $2)",
-		args.ToAccountID, args.Amount)
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      conv.toAmount,
+		ExchangeRate:  conv.rate,
+		Description:   arg.Description,
+		Reference:     arg.Reference,
+		Metadata:      metadata,
+	})
 	if err != nil {
-		return fail(err)
+		return result, ParseError(err)
 	}
 
-	// 5- subtract the amount from the AccountA (AccountA.balance - amount)
-	_, err = tx.ExecContext(ctx, "UPDATE accounts SET balance = balance - $1 WHERE id = $2",
-		args.Amount, args.FromAccountID)
-	if err != nil {
-		return fail(err)
-	}
-	// 6- add the amount to the AccountB (AccountB.balance + amount)
-	_, err = tx.ExecContext(ctx, "UPDATE accounts SET balance = balance + $1 WHERE id = $2",
-		args.Amount, args.ToAccountID)
-	if err != nil {
-		return fail(err)
+	// 3- Create FromAccount entry record, 4- Create toAccount entry record
+	postings := []posting{{accountID: arg.FromAccountID, amount: -arg.Amount, deferred: entryType == EntryTypeDeposit}}
+	if conv.exchanged() {
+		postings = append(postings,
+			posting{accountID: conv.fromHouseID, amount: arg.Amount, deferred: true},
+			posting{accountID: conv.toHouseID, amount: -conv.toAmount, deferred: true},
+		)
 	}
+	postings = append(postings, posting{accountID: arg.ToAccountID, amount: conv.toAmount, deferred: entryType == EntryTypeWithdrawal})
 
-	result.FromAccount, _ = store.Queries.GetAccount(ctx, args.FromAccountID)
-	result.ToAccount, _ = store.Queries.GetAccount(ctx, args.ToAccountID)
-	// Create a new row in the album_order table.
-	if err != nil {
-		return fail(err)
+	journal := sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+	for i, p := range postings {
+		entry, err := writePosting(ctx, q, p, journal, entryType)
+		if err != nil {
+			return result, err
+		}
+
+		switch i {
+		case 0:
+			result.FromEntry = entry
+		case len(postings) - 1:
+			result.ToEntry = entry
+		}
 	}
 
-	// Commit the transaction.
-	if err = tx.Commit(); err != nil {
-		return fail(err)
+	// 5- Subtract amount from fromAccount balance, 6- Add amount to toAccount balance
+	// in descending account id order so concurrent transfers lock accounts alike
+	sort.SliceStable(postings, func(i, j int) bool {
+		return postings[i].accountID > postings[j].accountID
+	})
+	for _, p := range postings {
+		if p.deferred {
+			continue
+		}
+		account, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: p.accountID, Amount: p.amount})
+		if err != nil {
+			return result, ParseError(err)
+		}
+
+		if account.ID == arg.FromAccountID {
+			result.FromAccount = account
+		}
+		if account.ID == arg.ToAccountID {
+			result.ToAccount = account
+		}
 	}
 
-	// Return the order ID.
 	return result, nil
 }
+
+// posting is a single entry of a transfer before it is written, the balance of the
+// account of a deferred posting is left to ApplyPendingPostings
+type posting struct {
+	accountID int64
+	amount    int64
+	deferred  bool
+}
+
+// writePosting write the entry of p in journal and queue it for the posting sweep
+// when it is deferred
+func writePosting(ctx context.Context, q *Queries, p posting, journal sql.NullInt64, entryType string) (Entry, error) {
+	entry, err := q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  p.accountID,
+		Amount:     p.amount,
+		TransferID: journal,
+		Type:       entryType,
+	})
+	if err != nil || !p.deferred {
+		return entry, err
+	}
+
+	err = q.CreatePendingPosting(ctx, CreatePendingPostingParams{
+		EntryID:   entry.ID,
+		AccountID: entry.AccountID,
+		Amount:    entry.Amount,
+	})
+	return entry, err
+}
@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/hamdysherif/simplebank/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -42,11 +44,15 @@ func TestTransferTx(t *testing.T) {
 		result := <-results
 		require.NotZero(t, result.FromEntry.ID)
 		require.Equal(t, result.FromEntry.Amount, -amount)
+		require.Equal(t, result.Transfer.ID, result.FromEntry.TransferID.Int64)
+		require.Equal(t, EntryTypeTransfer, result.FromEntry.Type)
 		_, fErr := testStore.GetEntry(context.Background(), result.FromEntry.ID)
 		require.NoError(t, fErr)
 
 		require.NotZero(t, result.ToEntry.ID)
 		require.Equal(t, result.ToEntry.Amount, amount)
+		require.Equal(t, result.Transfer.ID, result.ToEntry.TransferID.Int64)
+		require.Equal(t, EntryTypeTransfer, result.ToEntry.Type)
 		_, tErr := testStore.GetEntry(context.Background(), result.ToEntry.ID)
 		require.NoError(t, tErr)
 
@@ -109,50 +115,58 @@ func TestTransferTxDeadLock(t *testing.T) {
 	require.Equal(t, tAccount.Balance, account2.Balance)
 }
 
-func TestTransferTxPure(t *testing.T) {
+func TestIdempotentTransferTx(t *testing.T) {
 	testStore := NewStore(testDB)
 
+	user := createRandomUser(t)
 	fromAccount := createRandomAccount(t)
 	toAccount := createRandomAccount(t)
 
-	fmt.Println("Balance Beforee:", fromAccount.Balance, toAccount.Balance)
-	n := 10
-	amount := int64(10)
+	args := IdempotentTransferParams{
+		TransferParams: TransferParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        10,
+		},
+		Username:    user.Username,
+		Key:         util.RandomString(16),
+		RequestHash: util.RandomString(64),
+	}
 
-	errs := make(chan error)
-	results := make(chan TransferResult)
+	result, err := testStore.IdempotentTransferTx(context.Background(), args)
+	require.NoError(t, err)
+	require.NotZero(t, result.Transfer.ID)
 
-	args := TransferParams{
-		FromAccountID: fromAccount.ID,
-		ToAccountID:   toAccount.ID,
-		Amount:        amount,
-	}
+	stored, err := testStore.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: user.Username,
+		Key:      args.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, args.RequestHash, stored.RequestHash)
 
-	for i := 0; i < n; i++ {
-		go func(i int) {
-			result, err := testStore.TransferTxPure(context.Background(), args)
-			fmt.Println("Balance IN TX:", result.FromAccount.Balance, result.ToAccount.Balance, i)
-			errs <- err
-			results <- result
-		}(i)
-	}
+	var storedResult TransferResult
+	require.NoError(t, json.Unmarshal(stored.Response, &storedResult))
+	require.Equal(t, result.Transfer.ID, storedResult.Transfer.ID)
 
-	for i := 0; i < n; i++ {
-		err := <-errs
-		require.NoError(t, err)
-		result := <-results
-		require.NotZero(t, result.FromAccount.ID)
-		// check account balance
-		fromDiff := fromAccount.Balance - result.FromAccount.Balance
-		toDiff := result.ToAccount.Balance - toAccount.Balance
-		require.Equal(t, fromDiff, toDiff)
+	// reusing the key rolls the second transfer back
+	_, err = testStore.IdempotentTransferTx(context.Background(), args)
+	require.ErrorIs(t, err, ErrIdempotencyKeyExists)
 
-	}
+	account, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.FromAccount.Balance, account.Balance)
+}
 
-	fAccount, _ := testStore.GetAccount(context.Background(), fromAccount.ID)
-	tAccount, _ := testStore.GetAccount(context.Background(), toAccount.ID)
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	testStore := NewStore(testDB)
 
-	// balalnce after all operations
-	require.Equal(t, fAccount.Balance, fromAccount.Balance-int64(n)*amount)
-	require.Equal(t, tAccount.Balance, toAccount.Balance+int64(n)*amount)
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	_, err := testStore.TransferTx(context.Background(), TransferParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        fromAccount.Balance + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
 }
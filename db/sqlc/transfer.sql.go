@@ -5,22 +5,37 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
-INSERT INTO transfers(from_account_id, to_account_id, amount)
-VALUES ($1, $2, $3)
-RETURNING id, from_account_id, to_account_id, amount, created_at
+INSERT INTO transfers(from_account_id, to_account_id, amount, to_amount, exchange_rate, description, reference, metadata)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, description, reference, metadata
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	ToAmount      int64           `json:"to_amount"`
+	ExchangeRate  string          `json:"exchange_rate"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.Description,
+		arg.Reference,
+		arg.Metadata,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -28,12 +43,17 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-select id, from_account_id, to_account_id, amount, created_at from transfers WHERE id = $1 LIMIT 1
+select id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, description, reference, metadata from transfers WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
@@ -45,12 +65,17 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-select id, from_account_id, to_account_id, amount, created_at from transfers ORDER BY created_at DESC OFFSET $1 LIMIT $2
+select id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, description, reference, metadata from transfers ORDER BY created_at DESC OFFSET $1 LIMIT $2
 `
 
 type ListTransfersParams struct {
@@ -73,6 +98,11 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -88,7 +118,7 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 }
 
 const listTransfersByFrom = `-- name: ListTransfersByFrom :many
-select id, from_account_id, to_account_id, amount, created_at from transfers
+select id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, description, reference, metadata from transfers
 WHERE from_account_id = $1
 ORDER BY created_at DESC OFFSET $2 LIMIT $3
 `
@@ -114,6 +144,65 @@ func (q *Queries) ListTransfersByFrom(ctx context.Context, arg ListTransfersByFr
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTransfers = `-- name: SearchTransfers :many
+select id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, description, reference, metadata from transfers
+WHERE (from_account_id IN (SELECT id FROM accounts WHERE owner = $1)
+    OR to_account_id IN (SELECT id FROM accounts WHERE owner = $1))
+  AND ($2::varchar = '' OR reference = $2)
+ORDER BY id DESC OFFSET $3 LIMIT $4
+`
+
+type SearchTransfersParams struct {
+	Owner       string `json:"owner"`
+	Reference   string `json:"reference"`
+	OffsetCount int32  `json:"offset_count"`
+	LimitCount  int32  `json:"limit_count"`
+}
+
+func (q *Queries) SearchTransfers(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, searchTransfers,
+		arg.Owner,
+		arg.Reference,
+		arg.OffsetCount,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// tiers of users, each has its own transfer limits per currency
+const (
+	TierStandard = "standard"
+	TierPremium  = "premium"
+)
+
+// TransferAllowance is what a user can still send from their accounts in the currency
+// of Limit until ResetsAt
+type TransferAllowance struct {
+	Limit    TransferLimit
+	Usage    GetDailyTransferUsageRow
+	ResetsAt time.Time
+}
+
+// RemainingAmount is how much more can be sent today, no single transfer can exceed
+// Limit.MaxAmount though
+func (a TransferAllowance) RemainingAmount() int64 {
+	if a.Usage.TotalAmount >= a.Limit.DailyAmount {
+		return 0
+	}
+	return a.Limit.DailyAmount - a.Usage.TotalAmount
+}
+
+// RemainingCount is how many more transfers can be sent today
+func (a TransferAllowance) RemainingCount() int64 {
+	if a.Usage.TransferCount >= int64(a.Limit.DailyCount) {
+		return 0
+	}
+	return int64(a.Limit.DailyCount) - a.Usage.TransferCount
+}
+
+// startOfDay return the start of the UTC day of t, daily limits are counted from there
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// TransferAllowances return the allowance of user today in each currency its tier has
+// a limit in, currencies without a limit aren't capped
+func (store *SQLStore) TransferAllowances(ctx context.Context, user User, now time.Time) ([]TransferAllowance, error) {
+	limits, err := store.ListTransferLimitsByTier(ctx, user.Tier)
+	if err != nil {
+		return nil, err
+	}
+
+	since := startOfDay(now)
+	allowances := make([]TransferAllowance, len(limits))
+	for i, limit := range limits {
+		usage, err := store.GetDailyTransferUsage(ctx, GetDailyTransferUsageParams{
+			UserID:   user.ID,
+			Currency: limit.Currency,
+			Since:    since,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		allowances[i] = TransferAllowance{Limit: limit, Usage: usage, ResetsAt: since.AddDate(0, 0, 1)}
+	}
+
+	return allowances, nil
+}
+
+// checkTransferLimit fail with ErrTransferLimitExceeded when sending amount from the
+// account would go over the limits of the tier of its user in its currency. The usage
+// of the day is read in the transaction of the transfer, so concurrent transfers of
+// one user conflict rather than both slipping under the limit
+func checkTransferLimit(ctx context.Context, q *Queries, from Account, amount int64) error {
+	limit, err := q.GetTransferLimit(ctx, GetTransferLimitParams{
+		UserID:   from.UserID,
+		Currency: from.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if amount > limit.MaxAmount {
+		return fmt.Errorf("%w: amount is over the limit of a single transfer", ErrTransferLimitExceeded)
+	}
+
+	usage, err := q.GetDailyTransferUsage(ctx, GetDailyTransferUsageParams{
+		UserID:   from.UserID,
+		Currency: from.Currency,
+		Since:    startOfDay(time.Now()),
+	})
+	if err != nil {
+		return err
+	}
+
+	allowance := TransferAllowance{Limit: limit, Usage: usage}
+	if allowance.RemainingCount() == 0 {
+		return fmt.Errorf("%w: no transfers left today", ErrTransferLimitExceeded)
+	}
+	if amount > allowance.RemainingAmount() {
+		return fmt.Errorf("%w: amount is over what is left of the daily limit", ErrTransferLimitExceeded)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartOfDay(t *testing.T) {
+	cairo := time.FixedZone("EET", 2*60*60)
+	got := startOfDay(time.Date(2022, time.March, 1, 1, 30, 0, 0, cairo))
+	require.True(t, time.Date(2022, time.February, 28, 0, 0, 0, 0, time.UTC).Equal(got))
+}
+
+func TestTransferAllowanceRemaining(t *testing.T) {
+	allowance := TransferAllowance{
+		Limit: TransferLimit{MaxAmount: 100, DailyAmount: 300, DailyCount: 2},
+		Usage: GetDailyTransferUsageRow{TransferCount: 1, TotalAmount: 250},
+	}
+	require.Equal(t, int64(50), allowance.RemainingAmount())
+	require.Equal(t, int64(1), allowance.RemainingCount())
+
+	allowance.Usage = GetDailyTransferUsageRow{TransferCount: 3, TotalAmount: 320}
+	require.Zero(t, allowance.RemainingAmount())
+	require.Zero(t, allowance.RemainingCount())
+}
+
+func TestTransferLimitExceeded(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	limit, err := testQueries.GetTransferLimit(context.Background(), GetTransferLimitParams{
+		UserID:   from.UserID,
+		Currency: from.Currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TierStandard, limit.Tier)
+
+	_, err = testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: from.ID, Amount: limit.MaxAmount})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        limit.MaxAmount + 1,
+	})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	_, err = store.TransferTx(context.Background(), TransferParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10})
+	require.NoError(t, err)
+
+	user, err := testQueries.GetUser(context.Background(), from.UserID)
+	require.NoError(t, err)
+	allowances, err := store.TransferAllowances(context.Background(), user, time.Now())
+	require.NoError(t, err)
+
+	for _, allowance := range allowances {
+		if allowance.Limit.Currency == from.Currency {
+			require.Equal(t, int64(1), allowance.Usage.TransferCount)
+			require.Equal(t, int64(10), allowance.Usage.TotalAmount)
+		}
+	}
+}
+
+func TestDailyTransferUsageSelfTransfer(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 10})
+	require.NoError(t, err)
+
+	// both entries of a transfer to the same account are on its from account
+	_, err = store.TransferTx(context.Background(), TransferParams{FromAccountID: account.ID, ToAccountID: account.ID, Amount: 10})
+	require.NoError(t, err)
+
+	usage, err := testQueries.GetDailyTransferUsage(context.Background(), GetDailyTransferUsageParams{
+		UserID:   account.UserID,
+		Currency: account.Currency,
+		Since:    startOfDay(time.Now()),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), usage.TransferCount)
+	require.Equal(t, int64(10), usage.TotalAmount)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/hamdysherif/simplebank/util"
@@ -13,10 +14,16 @@ func createRandomTransfer(t *testing.T) Transfer {
 	fromAccount := createRandomAccount(t)
 	toAccount := createRandomAccount(t)
 
+	amount := util.RandomeBalance()
 	args := CreateTransferParams{
 		FromAccountID: fromAccount.ID,
 		ToAccountID:   toAccount.ID,
-		Amount:        util.RandomeBalance(),
+		Amount:        amount,
+		ToAmount:      amount,
+		ExchangeRate:  "1",
+		Description:   util.RandomString(12),
+		Reference:     util.RandomString(8),
+		Metadata:      json.RawMessage(`{"order_id": 42}`),
 	}
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), args)
@@ -25,6 +32,9 @@ func createRandomTransfer(t *testing.T) Transfer {
 	require.Equal(t, args.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, args.ToAccountID, transfer.ToAccountID)
 	require.Equal(t, args.Amount, transfer.Amount)
+	require.Equal(t, args.Description, transfer.Description)
+	require.Equal(t, args.Reference, transfer.Reference)
+	require.JSONEq(t, string(args.Metadata), string(transfer.Metadata))
 	return transfer
 }
 func TestCreateTransfer(t *testing.T) {
@@ -58,3 +68,41 @@ func TestListTransfers(t *testing.T) {
 		assert.NotEmpty(t, trans)
 	}
 }
+
+func TestSearchTransfers(t *testing.T) {
+	trans := createRandomTransfer(t)
+	from, err := testQueries.GetAccount(context.Background(), trans.FromAccountID)
+	require.NoError(t, err)
+	to, err := testQueries.GetAccount(context.Background(), trans.ToAccountID)
+	require.NoError(t, err)
+
+	// the transfer is found from both sides by its reference
+	for _, owner := range []string{from.Owner, to.Owner} {
+		transfers, err := testQueries.SearchTransfers(context.Background(), SearchTransfersParams{
+			Owner:       owner,
+			Reference:   trans.Reference,
+			OffsetCount: 0,
+			LimitCount:  5,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, trans.ID, transfers[0].ID)
+	}
+
+	transfers, err := testQueries.SearchTransfers(context.Background(), SearchTransfersParams{
+		Owner:       from.Owner,
+		OffsetCount: 0,
+		LimitCount:  5,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, transfers)
+
+	transfers, err = testQueries.SearchTransfers(context.Background(), SearchTransfersParams{
+		Owner:       from.Owner,
+		Reference:   util.RandomString(10),
+		OffsetCount: 0,
+		LimitCount:  5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
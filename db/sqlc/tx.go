@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// txMetrics count transaction retries, published by expvar under "db_tx"
+var txMetrics = expvar.NewMap("db_tx")
+
+// serializable is the isolation of transactions that read balances before writing them
+var serializable = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+// retryPolicy bound how often and how fast a transaction failing with a
+// serialization failure or a deadlock is run again
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{
+	maxAttempts: 5,
+	baseDelay:   10 * time.Millisecond,
+	maxDelay:    500 * time.Millisecond,
+}
+
+// backoff return a random delay up to baseDelay * 2^(attempt-1) capped at maxDelay
+func (policy retryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > policy.maxDelay {
+		delay = policy.maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// retryableTxError return the postgres error name when err aborted a
+// transaction that can succeed if run again
+func retryableTxError(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return "", false
+	}
+
+	switch name := pqErr.Code.Name(); name {
+	case "serialization_failure", "deadlock_detected":
+		return name, true
+	}
+	return "", false
+}
+
+// extend Store functionality to execute transactions
+func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	return store.execTxOptions(ctx, nil, fn)
+}
+
+// execTxOptions run fn in a transaction with opts, retrying it with jittered backoff
+// while postgres aborts it with a serialization failure or a deadlock, fn must
+// therefore be safe to run more than once. Once the policy is exhausted the last
+// error is returned as a *TxConflictError
+func (store *SQLStore) execTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, opts, fn)
+
+		reason, retryable := retryableTxError(err)
+		if !retryable {
+			return err
+		}
+		if attempt >= store.retry.maxAttempts {
+			txMetrics.Add("exhausted", 1)
+			return &TxConflictError{Reason: reason, Attempts: attempt, Err: err}
+		}
+
+		txMetrics.Add("retries", 1)
+		txMetrics.Add(reason, 1)
+
+		select {
+		case <-time.After(store.retry.backoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// runTx run fn once in a transaction, committing when it succeed
+func (store *SQLStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := New(tx)
+	err = fn(q)
+
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("transaction error: %w, rollback Error: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryableTxError(t *testing.T) {
+	reason, ok := retryableTxError(&pq.Error{Code: "40001"})
+	require.True(t, ok)
+	require.Equal(t, "serialization_failure", reason)
+
+	reason, ok = retryableTxError(fmt.Errorf("transfer: %w", &pq.Error{Code: "40P01"}))
+	require.True(t, ok)
+	require.Equal(t, "deadlock_detected", reason)
+
+	_, ok = retryableTxError(&pq.Error{Code: "23505"})
+	require.False(t, ok)
+
+	_, ok = retryableTxError(errors.New("connection refused"))
+	require.False(t, ok)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 5, baseDelay: 10 * time.Millisecond, maxDelay: 50 * time.Millisecond}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := policy.backoff(attempt)
+		require.GreaterOrEqual(t, delay, time.Duration(0))
+		require.LessOrEqual(t, delay, policy.maxDelay)
+	}
+	require.LessOrEqual(t, policy.backoff(1), policy.baseDelay)
+}
+
+func TestExecTxRetriesSerializationFailure(t *testing.T) {
+	store := &SQLStore{
+		db:      testDB,
+		Queries: New(testDB),
+		retry:   retryPolicy{maxAttempts: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond},
+	}
+	retriesBefore := txCounter("retries")
+
+	attempts := 0
+	err := store.execTxOptions(context.Background(), serializable, func(q *Queries) error {
+		attempts++
+		if attempts < 3 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, retriesBefore+2, txCounter("retries"))
+
+	// the last attempt's error is returned as a conflict once the policy is exhausted
+	attempts = 0
+	err = store.execTxOptions(context.Background(), serializable, func(q *Queries) error {
+		attempts++
+		return &pq.Error{Code: "40P01"}
+	})
+	require.ErrorIs(t, err, ErrTxConflict)
+	var conflictErr *TxConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, "deadlock_detected", conflictErr.Reason)
+	require.Equal(t, 3, conflictErr.Attempts)
+	_, retryable := retryableTxError(err)
+	require.True(t, retryable)
+	require.Equal(t, 3, attempts)
+}
+
+func txCounter(name string) int64 {
+	if v, ok := txMetrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
@@ -5,6 +5,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -13,7 +14,7 @@ INSERT INTO users (
 ) VALUES (
   $1, $2, $3, $4
 )
-RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at
+RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at, role, tier, tokens_revoked_at
 `
 
 type CreateUserParams struct {
@@ -40,12 +41,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.Tier,
+		&i.TokensRevokedAt,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at FROM users
+SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at, role, tier, tokens_revoked_at FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -61,12 +65,15 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.Tier,
+		&i.TokensRevokedAt,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at FROM users
+SELECT id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at, role, tier, tokens_revoked_at FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -82,6 +89,123 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.Tier,
+		&i.TokensRevokedAt,
+	)
+	return i, err
+}
+
+const getUserTokensRevokedAt = `-- name: GetUserTokensRevokedAt :one
+SELECT tokens_revoked_at FROM users
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserTokensRevokedAt(ctx context.Context, username string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getUserTokensRevokedAt, username)
+	var tokens_revoked_at time.Time
+	err := row.Scan(&tokens_revoked_at)
+	return tokens_revoked_at, err
+}
+
+const promoteFirstAdmin = `-- name: PromoteFirstAdmin :one
+UPDATE users
+SET role = 'admin', updated_at = now()
+WHERE username = $1 AND NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin')
+RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at, role, tier, tokens_revoked_at
+`
+
+func (q *Queries) PromoteFirstAdmin(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, promoteFirstAdmin, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.HashedPassword,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Tier,
+		&i.TokensRevokedAt,
+	)
+	return i, err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :one
+UPDATE users
+SET tokens_revoked_at = now(), updated_at = now()
+WHERE username = $1
+RETURNING tokens_revoked_at
+`
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, username string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, revokeUserTokens, username)
+	var tokens_revoked_at time.Time
+	err := row.Scan(&tokens_revoked_at)
+	return tokens_revoked_at, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2, password_changed_at = now(), tokens_revoked_at = now(), updated_at = now()
+WHERE username = $1
+RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at, role, tier, tokens_revoked_at
+`
+
+type UpdateUserPasswordParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.Username, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.HashedPassword,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Tier,
+		&i.TokensRevokedAt,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE username = $1
+RETURNING id, username, full_name, email, hashed_password, password_changed_at, created_at, updated_at, role, tier, tokens_revoked_at
+`
+
+type UpdateUserRoleParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.Username, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FullName,
+		&i.Email,
+		&i.HashedPassword,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Tier,
+		&i.TokensRevokedAt,
 	)
 	return i, err
 }
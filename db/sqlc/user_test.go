@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/hamdysherif/simplebank/util"
@@ -33,6 +34,24 @@ func TestCreateUser(t *testing.T) {
 	createRandomUser(t)
 }
 
+func TestCreateUserDuplicateUsername(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	_, err := testQueries.CreateUser(context.Background(), CreateUserParams{
+		FullName:       util.RandomOwner(),
+		Username:       user1.Username,
+		Email:          util.RandomEmail(),
+		HashedPassword: user1.HashedPassword,
+	})
+
+	err = ParseError(err)
+	require.ErrorIs(t, err, ErrUniqueViolation)
+
+	var constraintErr *ConstraintError
+	require.ErrorAs(t, err, &constraintErr)
+	require.Equal(t, "users_username_key", constraintErr.Constraint)
+}
+
 func TestGetUser(t *testing.T) {
 	user1 := createRandomUser(t)
 
@@ -56,3 +75,71 @@ func TestGetUserByUsername(t *testing.T) {
 	require.Equal(t, user1.Username, user.Username)
 	require.Equal(t, user1.Email, user.Email)
 }
+
+func TestUpdateUserPassword(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	hashedPassword, err := util.GenerateHashedPassowrd("new-secret")
+	require.NoError(t, err)
+
+	user, err := testQueries.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{
+		Username:       user1.Username,
+		HashedPassword: hashedPassword,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, user.HashedPassword)
+	require.True(t, user.PasswordChangedAt.After(user1.PasswordChangedAt))
+
+	require.True(t, user.TokensRevokedAt.Equal(user.PasswordChangedAt))
+
+	revokedAt, err := testQueries.GetUserTokensRevokedAt(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.True(t, revokedAt.Equal(user.PasswordChangedAt))
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	user1 := createRandomUser(t)
+	// a new user has nothing revoked
+	require.True(t, user1.TokensRevokedAt.Before(user1.CreatedAt))
+
+	revokedAt, err := testQueries.RevokeUserTokens(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.True(t, revokedAt.After(user1.TokensRevokedAt))
+
+	user, err := testQueries.GetUserByUsername(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.True(t, revokedAt.Equal(user.TokensRevokedAt))
+	require.True(t, user.PasswordChangedAt.Equal(user1.PasswordChangedAt))
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	user1 := createRandomUser(t)
+	require.Equal(t, util.RoleCustomer, user1.Role)
+
+	user, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user1.Username,
+		Role:     util.RoleTeller,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, user1.ID, user.ID)
+	require.Equal(t, util.RoleTeller, user.Role)
+}
+
+func TestPromoteFirstAdminWithAdmin(t *testing.T) {
+	admin := createRandomUser(t)
+	_, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: admin.Username,
+		Role:     util.RoleAdmin,
+	})
+	require.NoError(t, err)
+
+	user1 := createRandomUser(t)
+	_, err = testQueries.PromoteFirstAdmin(context.Background(), user1.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err := testQueries.GetUserByUsername(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.Equal(t, util.RoleCustomer, user.Role)
+}
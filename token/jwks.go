@@ -0,0 +1,66 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+)
+
+// PublicKeyMaker is a Maker whose tokens can be verified offline with public keys
+type PublicKeyMaker interface {
+	Maker
+
+	// PublicKeys return the verification keys by key id
+	PublicKeys() map[string]crypto.PublicKey
+
+	// Algorithm return the JWA name of the signature algorithm
+	Algorithm() string
+}
+
+// JWK is a public JSON Web Key as described in RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKSet is the document served to let other services verify tokens
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWKSet describe the verification keys of maker ordered by key id
+func NewJWKSet(maker PublicKeyMaker) JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for id, publicKey := range maker.PublicKeys() {
+		jwk := JWK{KeyID: id, Use: "sig", Algorithm: maker.Algorithm()}
+
+		switch key := publicKey.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(key)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+	return set
+}
@@ -10,30 +10,48 @@ import (
 const secretKeyAllowedLength = 32
 
 type JWTMaker struct {
-	secretKey string
+	keyring *Keyring
 }
 
 func NewJWTMaker(secretKey string) (Maker, error) {
-	if len(secretKey) < secretKeyAllowedLength {
-		return nil, fmt.Errorf("secret key length should not be less than %d", secretKeyAllowedLength)
+	return NewJWTMakerWithKeyring(SingleKeyring(secretKey))
+}
+
+// NewJWTMakerWithKeyring create a jwt maker signing with the active key of keyring
+func NewJWTMakerWithKeyring(keyring *Keyring) (Maker, error) {
+	err := keyring.validate(func(secret []byte) error {
+		if len(secret) < secretKeyAllowedLength {
+			return fmt.Errorf("secret key length should not be less than %d", secretKeyAllowedLength)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return &JWTMaker{secretKey}, nil
+	return &JWTMaker{keyring}, nil
 }
 
-func (jwtMaker *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
+func (jwtMaker *JWTMaker) CreateToken(username string, role string, kind string, duration time.Duration) (string, *Payload, error) {
 
-	payload, err := NewPaylod(username, duration)
+	payload, err := NewPaylod(username, role, kind, duration)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	// Create a new token object, specifying signing method and the claims
 	// you would like it to contain.
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 
+	// The kid header tells VerifyToken which key of the ring to check against
+	keyID, secretKey := jwtMaker.keyring.Active()
+	token.Header["kid"] = keyID
+
 	// Sign and get the complete encoded token as a string using the secret
-	tokenString, err := token.SignedString([]byte(jwtMaker.secretKey))
+	tokenString, err := token.SignedString(secretKey)
+	if err != nil {
+		return "", nil, err
+	}
 
-	return tokenString, err
+	return tokenString, payload, nil
 }
 
 func (jwtMaker *JWTMaker) VerifyToken(token string) (*Payload, error) {
@@ -46,13 +64,27 @@ func (jwtMaker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		if !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(jwtMaker.secretKey), nil
+
+		keyID, _ := token.Header["kid"].(string)
+		secretKey, ok := jwtMaker.keyring.Key(keyID)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return secretKey, nil
 	}
 
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
 	if err != nil {
-		return nil, err
+		return nil, jwtVerifyError(err)
 	}
 
 	return jwtToken.Claims.(*Payload), nil
 }
+
+// jwtVerifyError reduce the errors of jwt.ParseWithClaims to ErrExpiredToken or ErrInvalidToken
+func jwtVerifyError(err error) error {
+	if verr, ok := err.(*jwt.ValidationError); ok && verr.Inner == ErrExpiredToken {
+		return ErrExpiredToken
+	}
+	return ErrInvalidToken
+}
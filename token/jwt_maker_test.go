@@ -15,17 +15,21 @@ func TestGenerateJWTToken(t *testing.T) {
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(time.Minute)
-	token, err := maker.CreateToken(username, time.Minute)
+	token, createdPayload, err := maker.CreateToken(username, util.RoleCustomer, AccessToken, time.Minute)
 
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, createdPayload)
 
 	payload, err := maker.VerifyToken(token)
 	require.NoError(t, err)
 	require.Equal(t, payload.Username, username)
+	require.Equal(t, util.RoleCustomer, payload.Role)
+	require.Equal(t, AccessToken, payload.Kind)
 	require.NotZero(t, payload.ID)
 	require.WithinDuration(t, payload.ExpireAt, expiredAt, time.Second)
 	require.WithinDuration(t, payload.IssuedAt, issuedAt, time.Second)
+	require.Equal(t, createdPayload.ID, payload.ID)
 }
 
 func TestExpireJWTToken(t *testing.T) {
@@ -33,7 +37,7 @@ func TestExpireJWTToken(t *testing.T) {
 	require.NoError(t, err)
 	username := util.RandomOwner()
 
-	token, err := maker.CreateToken(username, -time.Minute)
+	token, _, err := maker.CreateToken(username, util.RoleCustomer, AccessToken, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -42,3 +46,32 @@ func TestExpireJWTToken(t *testing.T) {
 	require.Nil(t, payload)
 	require.Error(t, err, ErrExpiredToken)
 }
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldKeyring, err := NewKeyring("k1", map[string]string{"k1": "12345678901234567890123456789012"})
+	require.NoError(t, err)
+	oldMaker, err := NewJWTMakerWithKeyring(oldKeyring)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), util.RoleCustomer, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	rotatedKeyring, err := NewKeyring("k2", map[string]string{
+		"k1": "12345678901234567890123456789012",
+		"k2": "abcdefghijklmnopqrstuvwxyz123456",
+	})
+	require.NoError(t, err)
+	rotatedMaker, err := NewJWTMakerWithKeyring(rotatedKeyring)
+	require.NoError(t, err)
+
+	_, err = rotatedMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	retiredKeyring, err := NewKeyring("k2", map[string]string{"k2": "abcdefghijklmnopqrstuvwxyz123456"})
+	require.NoError(t, err)
+	retiredMaker, err := NewJWTMakerWithKeyring(retiredKeyring)
+	require.NoError(t, err)
+
+	_, err = retiredMaker.VerifyToken(oldToken)
+	require.Error(t, err)
+}
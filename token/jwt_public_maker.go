@@ -0,0 +1,91 @@
+package token
+
+import (
+	"crypto"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const rsaMinKeyBits = 2048
+
+// JWTPublicMaker sign jwt tokens with an asymmetric key (EdDSA or RS256),
+// anyone holding the public keys can verify them
+type JWTPublicMaker struct {
+	keyring *SigningKeyring
+	method  jwt.SigningMethod
+}
+
+// NewJWTPublicMaker create a jwt maker signing with the active key of keyring using
+// method, which must be jwt.SigningMethodEdDSA or jwt.SigningMethodRS256
+func NewJWTPublicMaker(keyring *SigningKeyring, method jwt.SigningMethod) (Maker, error) {
+	var err error
+	switch method {
+	case jwt.SigningMethodEdDSA:
+		err = keyring.requireEd25519()
+	case jwt.SigningMethodRS256:
+		err = keyring.requireRSA(rsaMinKeyBits)
+	default:
+		err = errors.New("unsupported signing method")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTPublicMaker{keyring: keyring, method: method}, nil
+}
+
+// CreateToken create and sign a token of kind for username holding role with duration
+func (maker *JWTPublicMaker) CreateToken(username string, role string, kind string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPaylod(username, role, kind, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	keyID, privateKey := maker.keyring.Signer()
+	token := jwt.NewWithClaims(maker.method, payload)
+	token.Header["kid"] = keyID
+
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tokenString, payload, nil
+}
+
+// VerifyToken verify the token and return the decoded payload
+func (maker *JWTPublicMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		// only the configured algorithm is accepted, never "none" or HMAC
+		// signed with the public key
+		if token.Method.Alg() != maker.method.Alg() {
+			return nil, ErrInvalidToken
+		}
+
+		keyID, _ := token.Header["kid"].(string)
+		publicKey, ok := maker.keyring.PublicKey(keyID)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return publicKey, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		return nil, jwtVerifyError(err)
+	}
+
+	return jwtToken.Claims.(*Payload), nil
+}
+
+// PublicKeys return the verification keys by key id
+func (maker *JWTPublicMaker) PublicKeys() map[string]crypto.PublicKey {
+	return maker.keyring.PublicKeys()
+}
+
+// Algorithm return the JWA name of the signature algorithm
+func (maker *JWTPublicMaker) Algorithm() string {
+	return maker.method.Alg()
+}
@@ -0,0 +1,96 @@
+package token
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateJWTPublicToken(t *testing.T) {
+	testCases := map[string]struct {
+		method     jwt.SigningMethod
+		privateKey func(t *testing.T) crypto.Signer
+	}{
+		"EdDSA": {
+			method:     jwt.SigningMethodEdDSA,
+			privateKey: func(t *testing.T) crypto.Signer { return randomEd25519Key(t) },
+		},
+		"RS256": {
+			method:     jwt.SigningMethodRS256,
+			privateKey: func(t *testing.T) crypto.Signer { return randomRSAKey(t) },
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			keyring := newTestSigningKeyring(t, "k1", map[string]crypto.Signer{"k1": tc.privateKey(t)})
+			maker, err := NewJWTPublicMaker(keyring, tc.method)
+			require.NoError(t, err)
+			username := util.RandomOwner()
+
+			token, createdPayload, err := maker.CreateToken(username, util.RoleCustomer, AccessToken, time.Minute)
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, username, payload.Username)
+			require.Equal(t, createdPayload.ID, payload.ID)
+		})
+	}
+}
+
+func TestJWTPublicTokenWrongAlgorithm(t *testing.T) {
+	privateKey := randomEd25519Key(t)
+	keyring := newTestSigningKeyring(t, "k1", map[string]crypto.Signer{"k1": privateKey})
+	maker, err := NewJWTPublicMaker(keyring, jwt.SigningMethodEdDSA)
+	require.NoError(t, err)
+
+	// an unsigned token must never be accepted
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, &Payload{Username: util.RandomOwner(), ExpireAt: time.Now().Add(time.Minute)})
+	unsigned.Header["kid"] = "k1"
+	token, err := unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.Error(t, err)
+	require.Nil(t, payload)
+}
+
+func TestJWTPublicMakerKeyMismatch(t *testing.T) {
+	keyring := newTestSigningKeyring(t, "k1", map[string]crypto.Signer{"k1": randomEd25519Key(t)})
+	_, err := NewJWTPublicMaker(keyring, jwt.SigningMethodRS256)
+	require.Error(t, err)
+
+	_, err = NewJWTPublicMaker(keyring, jwt.SigningMethodHS256)
+	require.Error(t, err)
+}
+
+func TestNewJWKSet(t *testing.T) {
+	keyring := newTestSigningKeyring(t, "ed", map[string]crypto.Signer{"ed": randomEd25519Key(t)})
+	maker, err := NewJWTPublicMaker(keyring, jwt.SigningMethodEdDSA)
+	require.NoError(t, err)
+
+	set := NewJWKSet(maker.(PublicKeyMaker))
+	require.Len(t, set.Keys, 1)
+	require.Equal(t, "ed", set.Keys[0].KeyID)
+	require.Equal(t, "OKP", set.Keys[0].KeyType)
+	require.Equal(t, "Ed25519", set.Keys[0].Curve)
+	require.Equal(t, "EdDSA", set.Keys[0].Algorithm)
+	require.NotEmpty(t, set.Keys[0].X)
+
+	rsaKeyring := newTestSigningKeyring(t, "rsa", map[string]crypto.Signer{"rsa": randomRSAKey(t)})
+	rsaMaker, err := NewJWTPublicMaker(rsaKeyring, jwt.SigningMethodRS256)
+	require.NoError(t, err)
+
+	set = NewJWKSet(rsaMaker.(PublicKeyMaker))
+	require.Len(t, set.Keys, 1)
+	require.Equal(t, "RSA", set.Keys[0].KeyType)
+	require.Equal(t, "RS256", set.Keys[0].Algorithm)
+	require.Equal(t, "AQAB", set.Keys[0].E)
+	require.NotEmpty(t, set.Keys[0].N)
+}
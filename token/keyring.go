@@ -0,0 +1,91 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultKeyID is the id given to a single key configured without one
+const DefaultKeyID = "default"
+
+// Keyring hold the secrets a Maker uses by key id, tokens are always signed
+// with the active key while any key still on the ring can verify them,
+// so a key is retired by removing it from the ring
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewKeyring create a keyring from key id -> secret with activeID used for signing
+func NewKeyring(activeID string, keys map[string]string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring needs at least one key")
+	}
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", activeID)
+	}
+
+	keyring := &Keyring{
+		activeID: activeID,
+		keys:     make(map[string][]byte, len(keys)),
+	}
+	for id, secret := range keys {
+		if id == "" {
+			return nil, errors.New("key id can't be empty")
+		}
+		keyring.keys[id] = []byte(secret)
+	}
+
+	return keyring, nil
+}
+
+// ParseKeyring create a keyring from "id:secret" entries with activeID used for signing
+func ParseKeyring(activeID string, entries []string) (*Keyring, error) {
+	keys := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("key entry should be in the form id:secret")
+		}
+		if _, ok := keys[parts[0]]; ok {
+			return nil, fmt.Errorf("duplicated key id %q", parts[0])
+		}
+		keys[parts[0]] = parts[1]
+	}
+
+	return NewKeyring(activeID, keys)
+}
+
+// SingleKeyring create a keyring holding only secret under DefaultKeyID
+func SingleKeyring(secret string) *Keyring {
+	return &Keyring{
+		activeID: DefaultKeyID,
+		keys:     map[string][]byte{DefaultKeyID: []byte(secret)},
+	}
+}
+
+// Active return the id and secret of the signing key
+func (keyring *Keyring) Active() (string, []byte) {
+	return keyring.activeID, keyring.keys[keyring.activeID]
+}
+
+// Key return the secret of key id, tokens without a key id were issued
+// before rotation was introduced and are checked against the active key
+func (keyring *Keyring) Key(id string) ([]byte, bool) {
+	if id == "" {
+		id = keyring.activeID
+	}
+	secret, ok := keyring.keys[id]
+	return secret, ok
+}
+
+// validate check every secret on the ring against a maker's size requirement
+func (keyring *Keyring) validate(check func(secret []byte) error) error {
+	for id, secret := range keyring.keys {
+		if err := check(secret); err != nil {
+			return fmt.Errorf("key %q: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeyring(t *testing.T) {
+	keyring, err := ParseKeyring("k2", []string{
+		"k1:12345678901234567890123456789012",
+		"k2:abcdefghijklmnopqrstuvwxyz123456",
+	})
+	require.NoError(t, err)
+
+	id, secret := keyring.Active()
+	require.Equal(t, "k2", id)
+	require.Equal(t, []byte("abcdefghijklmnopqrstuvwxyz123456"), secret)
+
+	secret, ok := keyring.Key("k1")
+	require.True(t, ok)
+	require.Equal(t, []byte("12345678901234567890123456789012"), secret)
+
+	_, ok = keyring.Key("k3")
+	require.False(t, ok)
+}
+
+func TestParseKeyringErrors(t *testing.T) {
+	testCases := map[string]struct {
+		activeID string
+		entries  []string
+	}{
+		"NoKeys":          {activeID: "k1", entries: nil},
+		"MissingActive":   {activeID: "k2", entries: []string{"k1:secret"}},
+		"MalformedEntry":  {activeID: "k1", entries: []string{"k1"}},
+		"DuplicatedKeyID": {activeID: "k1", entries: []string{"k1:secret", "k1:other"}},
+		"EmptyKeyID":      {activeID: "", entries: []string{":secret"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseKeyring(tc.activeID, tc.entries)
+			require.Error(t, err)
+		})
+	}
+}
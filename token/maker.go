@@ -6,8 +6,9 @@ import (
 
 type Maker interface {
 
-	// CreateToken create and sign a token for username with duration
-	CreateToken(username string, duration time.Duration) (string, error)
+	// CreateToken create and sign a token of kind for username holding role with duration
+	// it returns the signed token along with its payload
+	CreateToken(username string, role string, kind string, duration time.Duration) (string, *Payload, error)
 
 	// VerifyToken verify the token and return the decoded payload
 	VerifyToken(token string) (*Payload, error)
@@ -9,39 +9,71 @@ import (
 )
 
 type Pasetomaker struct {
-	symmetricKey []byte
-	paseto       *paseto.V2
+	keyring *Keyring
+	paseto  *paseto.V2
+}
+
+// pasetoFooter is the unencrypted footer identifying the key a token was signed with
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
 }
 
 func NewPasetoMaker(symmetricKey string) (Maker, error) {
-	if len(symmetricKey) < chacha20poly1305.KeySize {
-		return nil, fmt.Errorf("secret symmetricKey length should not be less than %d", chacha20poly1305.KeySize)
+	return NewPasetoMakerWithKeyring(SingleKeyring(symmetricKey))
+}
+
+// NewPasetoMakerWithKeyring create a paseto maker signing with the active key of keyring
+func NewPasetoMakerWithKeyring(keyring *Keyring) (Maker, error) {
+	err := keyring.validate(func(secret []byte) error {
+		if len(secret) != chacha20poly1305.KeySize {
+			return fmt.Errorf("secret symmetricKey length should be exactly %d", chacha20poly1305.KeySize)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
 	return &Pasetomaker{
-		symmetricKey: []byte(symmetricKey),
-		paseto:       paseto.NewV2(),
+		keyring: keyring,
+		paseto:  paseto.NewV2(),
 	}, nil
 }
 
-// CreateToken create and sign a token for username with duration
-func (maker *Pasetomaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPaylod(username, duration)
+// CreateToken create and sign a token of kind for username holding role with duration
+func (maker *Pasetomaker) CreateToken(username string, role string, kind string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPaylod(username, role, kind, duration)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	token, err := maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+	keyID, symmetricKey := maker.keyring.Active()
+	token, err := maker.paseto.Encrypt(symmetricKey, payload, pasetoFooter{KeyID: keyID})
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return token, nil
+	return token, payload, nil
 }
 
 // VerifyToken verify the token and return the decoded payload
 func (maker *Pasetomaker) VerifyToken(token string) (*Payload, error) {
+	var footer pasetoFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	symmetricKey, ok := maker.keyring.Key(footer.KeyID)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
 	payload := &Payload{}
-	err := maker.paseto.Decrypt(token, maker.symmetricKey, payload, nil)
+	err := maker.paseto.Decrypt(token, symmetricKey, payload, nil)
 	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := payload.Valid(); err != nil {
 		return nil, err
 	}
 
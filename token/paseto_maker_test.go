@@ -15,15 +15,69 @@ func TestGeneratePasetoToken(t *testing.T) {
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(time.Minute)
-	token, err := maker.CreateToken(username, time.Minute)
+	token, createdPayload, err := maker.CreateToken(username, util.RoleCustomer, AccessToken, time.Minute)
 
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, createdPayload)
 
 	payload, err := maker.VerifyToken(token)
 	require.NoError(t, err)
 	require.Equal(t, payload.Username, username)
+	require.Equal(t, util.RoleCustomer, payload.Role)
+	require.Equal(t, AccessToken, payload.Kind)
 	require.NotZero(t, payload.ID)
 	require.WithinDuration(t, payload.ExpireAt, expiredAt, time.Second)
 	require.WithinDuration(t, payload.IssuedAt, issuedAt, time.Second)
+	require.Equal(t, createdPayload.ID, payload.ID)
+}
+
+func TestPasetoKeyRotation(t *testing.T) {
+	oldKeyring, err := NewKeyring("k1", map[string]string{"k1": "12345678901234567890123456789012"})
+	require.NoError(t, err)
+	oldMaker, err := NewPasetoMakerWithKeyring(oldKeyring)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), util.RoleCustomer, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// k2 becomes the signing key while k1 is still accepted
+	rotatedKeyring, err := NewKeyring("k2", map[string]string{
+		"k1": "12345678901234567890123456789012",
+		"k2": "abcdefghijklmnopqrstuvwxyz123456",
+	})
+	require.NoError(t, err)
+	rotatedMaker, err := NewPasetoMakerWithKeyring(rotatedKeyring)
+	require.NoError(t, err)
+
+	_, err = rotatedMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	newToken, _, err := rotatedMaker.CreateToken(util.RandomOwner(), util.RoleCustomer, AccessToken, time.Minute)
+	require.NoError(t, err)
+	_, err = oldMaker.VerifyToken(newToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// once k1 is retired its tokens are rejected
+	retiredKeyring, err := NewKeyring("k2", map[string]string{"k2": "abcdefghijklmnopqrstuvwxyz123456"})
+	require.NoError(t, err)
+	retiredMaker, err := NewPasetoMakerWithKeyring(retiredKeyring)
+	require.NoError(t, err)
+
+	_, err = retiredMaker.VerifyToken(oldToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	_, err = retiredMaker.VerifyToken(newToken)
+	require.NoError(t, err)
+}
+
+func TestExpirePasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker("12345678901234567890123456789023")
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.RoleCustomer, AccessToken, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
 }
@@ -0,0 +1,76 @@
+package token
+
+import (
+	"crypto"
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+// PasetoPublicMaker sign v2.public tokens with ed25519 keys, anyone holding
+// the public keys can verify them
+type PasetoPublicMaker struct {
+	keyring *SigningKeyring
+	paseto  *paseto.V2
+}
+
+// NewPasetoPublicMaker create a v2.public paseto maker signing with the active key of keyring
+func NewPasetoPublicMaker(keyring *SigningKeyring) (Maker, error) {
+	if err := keyring.requireEd25519(); err != nil {
+		return nil, err
+	}
+
+	return &PasetoPublicMaker{
+		keyring: keyring,
+		paseto:  paseto.NewV2(),
+	}, nil
+}
+
+// CreateToken create and sign a token of kind for username holding role with duration
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, kind string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPaylod(username, role, kind, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	keyID, privateKey := maker.keyring.Signer()
+	token, err := maker.paseto.Sign(privateKey, payload, pasetoFooter{KeyID: keyID})
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
+}
+
+// VerifyToken verify the token and return the decoded payload
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	var footer pasetoFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	publicKey, ok := maker.keyring.PublicKey(footer.KeyID)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	if err := maker.paseto.Verify(token, publicKey, payload, nil); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := payload.Valid(); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// PublicKeys return the verification keys by key id
+func (maker *PasetoPublicMaker) PublicKeys() map[string]crypto.PublicKey {
+	return maker.keyring.PublicKeys()
+}
+
+// Algorithm return the JWA name of the signature algorithm
+func (maker *PasetoPublicMaker) Algorithm() string {
+	return "EdDSA"
+}
@@ -0,0 +1,58 @@
+package token
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/hamdysherif/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePasetoPublicToken(t *testing.T) {
+	keyring := newTestSigningKeyring(t, "k1", map[string]crypto.Signer{"k1": randomEd25519Key(t)})
+	maker, err := NewPasetoPublicMaker(keyring)
+	require.NoError(t, err)
+	username := util.RandomOwner()
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(time.Minute)
+	token, createdPayload, err := maker.CreateToken(username, util.RoleCustomer, AccessToken, time.Minute)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, createdPayload)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.Username, username)
+	require.Equal(t, util.RoleCustomer, payload.Role)
+	require.Equal(t, AccessToken, payload.Kind)
+	require.Equal(t, createdPayload.ID, payload.ID)
+	require.WithinDuration(t, payload.ExpireAt, expiredAt, time.Second)
+	require.WithinDuration(t, payload.IssuedAt, issuedAt, time.Second)
+}
+
+func TestPasetoPublicTokenOtherKey(t *testing.T) {
+	keyring := newTestSigningKeyring(t, "k1", map[string]crypto.Signer{"k1": randomEd25519Key(t)})
+	maker, err := NewPasetoPublicMaker(keyring)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.RoleCustomer, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// same key id, different key
+	otherKeyring := newTestSigningKeyring(t, "k1", map[string]crypto.Signer{"k1": randomEd25519Key(t)})
+	otherMaker, err := NewPasetoPublicMaker(otherKeyring)
+	require.NoError(t, err)
+
+	payload, err := otherMaker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicMakerRejectsRSA(t *testing.T) {
+	keyring := newTestSigningKeyring(t, "k1", map[string]crypto.Signer{"k1": randomRSAKey(t)})
+	_, err := NewPasetoPublicMaker(keyring)
+	require.Error(t, err)
+}
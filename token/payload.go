@@ -12,10 +12,18 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 )
 
+// kinds of token, a refresh token is only good for getting a new access token
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
 // Payload a definition for the payload
 type Payload struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	Kind     string    `json:"kind"`
 	IssuedAt time.Time `json:"issued_at"`
 	ExpireAt time.Time `json:"expire_at"`
 }
@@ -28,8 +36,8 @@ func (payload *Payload) Valid() error {
 	return nil
 }
 
-// NewPaylod return a new payload for username and duration
-func NewPaylod(username string, duration time.Duration) (*Payload, error) {
+// NewPaylod return a new payload of kind for username with role and duration
+func NewPaylod(username string, role string, kind string, duration time.Duration) (*Payload, error) {
 	uuid, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -37,6 +45,8 @@ func NewPaylod(username string, duration time.Duration) (*Payload, error) {
 	payload := &Payload{
 		ID:       uuid,
 		Username: username,
+		Role:     role,
+		Kind:     kind,
 		IssuedAt: time.Now(),
 		ExpireAt: time.Now().Add(duration),
 	}
@@ -0,0 +1,172 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SigningKeyring is the asymmetric counterpart of Keyring: tokens are signed
+// with the private key of the active id and verified with the public key
+// their id names, so verifiers never need to hold a private key
+type SigningKeyring struct {
+	activeID   string
+	signer     crypto.Signer
+	publicKeys map[string]crypto.PublicKey
+}
+
+// NewSigningKeyring create a keyring signing with privateKeys[activeID], the public half
+// of every private key is accepted for verification along with publicKeys
+func NewSigningKeyring(activeID string, privateKeys map[string]crypto.Signer, publicKeys map[string]crypto.PublicKey) (*SigningKeyring, error) {
+	signer, ok := privateKeys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q has no private key", activeID)
+	}
+
+	keyring := &SigningKeyring{
+		activeID:   activeID,
+		signer:     signer,
+		publicKeys: make(map[string]crypto.PublicKey, len(privateKeys)+len(publicKeys)),
+	}
+	for id, publicKey := range publicKeys {
+		keyring.publicKeys[id] = publicKey
+	}
+	for id, privateKey := range privateKeys {
+		if id == "" {
+			return nil, errors.New("key id can't be empty")
+		}
+		if _, ok := keyring.publicKeys[id]; ok {
+			return nil, fmt.Errorf("duplicated key id %q", id)
+		}
+		keyring.publicKeys[id] = privateKey.Public()
+	}
+
+	return keyring, nil
+}
+
+// LoadSigningKeyring create a keyring from "id:path" entries pointing to PEM encoded
+// PKCS#8 (or PKCS#1 RSA) private keys and PKIX public keys
+func LoadSigningKeyring(activeID string, privateKeyFiles []string, publicKeyFiles []string) (*SigningKeyring, error) {
+	privateKeys := make(map[string]crypto.Signer, len(privateKeyFiles))
+	err := readKeyFiles(privateKeyFiles, func(id string, block *pem.Block) error {
+		privateKey, err := parsePrivateKey(block)
+		if err != nil {
+			return err
+		}
+		privateKeys[id] = privateKey
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeys := make(map[string]crypto.PublicKey, len(publicKeyFiles))
+	err = readKeyFiles(publicKeyFiles, func(id string, block *pem.Block) error {
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return err
+		}
+		publicKeys[id] = publicKey
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSigningKeyring(activeID, privateKeys, publicKeys)
+}
+
+// Signer return the id and private key used for signing
+func (keyring *SigningKeyring) Signer() (string, crypto.Signer) {
+	return keyring.activeID, keyring.signer
+}
+
+// PublicKey return the public key of key id, tokens without a key id
+// are checked against the active key
+func (keyring *SigningKeyring) PublicKey(id string) (crypto.PublicKey, bool) {
+	if id == "" {
+		id = keyring.activeID
+	}
+	publicKey, ok := keyring.publicKeys[id]
+	return publicKey, ok
+}
+
+// PublicKeys return every verification key by key id
+func (keyring *SigningKeyring) PublicKeys() map[string]crypto.PublicKey {
+	publicKeys := make(map[string]crypto.PublicKey, len(keyring.publicKeys))
+	for id, publicKey := range keyring.publicKeys {
+		publicKeys[id] = publicKey
+	}
+	return publicKeys
+}
+
+// requireEd25519 make sure every key of the ring is an ed25519 key
+func (keyring *SigningKeyring) requireEd25519() error {
+	for id, publicKey := range keyring.publicKeys {
+		if _, ok := publicKey.(ed25519.PublicKey); !ok {
+			return fmt.Errorf("key %q is not an ed25519 key", id)
+		}
+	}
+	return nil
+}
+
+// requireRSA make sure every key of the ring is an rsa key of at least minBits
+func (keyring *SigningKeyring) requireRSA(minBits int) error {
+	for id, publicKey := range keyring.publicKeys {
+		rsaKey, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key %q is not an rsa key", id)
+		}
+		if rsaKey.N.BitLen() < minBits {
+			return fmt.Errorf("key %q should not be less than %d bits", id, minBits)
+		}
+	}
+	return nil
+}
+
+func readKeyFiles(entries []string, parse func(id string, block *pem.Block) error) error {
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("key entry should be in the form id:path")
+		}
+
+		data, err := os.ReadFile(parts[1])
+		if err != nil {
+			return fmt.Errorf("can't read key %q: %w", parts[0], err)
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return fmt.Errorf("key %q is not PEM encoded", parts[0])
+		}
+
+		if err := parse(parts[0], block); err != nil {
+			return fmt.Errorf("can't parse key %q: %w", parts[0], err)
+		}
+	}
+	return nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	return signer, nil
+}
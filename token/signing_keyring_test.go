@@ -0,0 +1,75 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func randomRSAKey(t *testing.T) *rsa.PrivateKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaMinKeyBits)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func newTestSigningKeyring(t *testing.T, activeID string, privateKeys map[string]crypto.Signer) *SigningKeyring {
+	keyring, err := NewSigningKeyring(activeID, privateKeys, nil)
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestLoadSigningKeyring(t *testing.T) {
+	dir := t.TempDir()
+
+	privateKey := randomEd25519Key(t)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	privatePath := filepath.Join(dir, "k2.pem")
+	err = os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+
+	retiredKey := randomEd25519Key(t)
+	der, err = x509.MarshalPKIXPublicKey(retiredKey.Public())
+	require.NoError(t, err)
+	publicPath := filepath.Join(dir, "k1.pub.pem")
+	err = os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+
+	keyring, err := LoadSigningKeyring("k2", []string{fmt.Sprintf("k2:%s", privatePath)}, []string{fmt.Sprintf("k1:%s", publicPath)})
+	require.NoError(t, err)
+
+	id, signer := keyring.Signer()
+	require.Equal(t, "k2", id)
+	require.Equal(t, privateKey.Public(), signer.Public())
+
+	publicKey, ok := keyring.PublicKey("k1")
+	require.True(t, ok)
+	require.Equal(t, retiredKey.Public(), publicKey)
+	require.Len(t, keyring.PublicKeys(), 2)
+}
+
+func TestLoadSigningKeyringErrors(t *testing.T) {
+	_, err := LoadSigningKeyring("k1", []string{"k1"}, nil)
+	require.Error(t, err)
+
+	_, err = LoadSigningKeyring("k1", []string{"k1:/does/not/exist.pem"}, nil)
+	require.Error(t, err)
+
+	_, err = NewSigningKeyring("k2", map[string]crypto.Signer{"k1": randomEd25519Key(t)}, nil)
+	require.Error(t, err)
+}
@@ -8,12 +8,24 @@ import (
 
 // Config store all configuration
 type Config struct {
-	DBDriver      string        `mapstructure:"DB_DRIVER"`
-	DBSource      string        `mapstructure:"DB_SOURCE"`
-	DBSourceTest  string        `mapstructure:"DB_SOURCE_TEST"`
-	ServerAddress string        `mapstructure:"SERVER_ADDRESS"`
-	SemmetricKey  string        `mapstructure:"SYMMETRIC_KEY"`
-	TokenDuration time.Duration `mapstructure:"TOKEN_DURATION"`
+	DBDriver                  string        `mapstructure:"DB_DRIVER"`
+	DBSource                  string        `mapstructure:"DB_SOURCE"`
+	DBSourceTest              string        `mapstructure:"DB_SOURCE_TEST"`
+	ServerAddress             string        `mapstructure:"SERVER_ADDRESS"`
+	TokenType                 string        `mapstructure:"TOKEN_TYPE"`
+	SemmetricKey              string        `mapstructure:"SYMMETRIC_KEY"`
+	TokenKeys                 []string      `mapstructure:"TOKEN_KEYS"`
+	TokenPrivateKeys          []string      `mapstructure:"TOKEN_PRIVATE_KEYS"`
+	TokenPublicKeys           []string      `mapstructure:"TOKEN_PUBLIC_KEYS"`
+	TokenActiveKeyID          string        `mapstructure:"TOKEN_ACTIVE_KEY_ID"`
+	TokenDuration             time.Duration `mapstructure:"TOKEN_DURATION"`
+	RefreshTokenDuration      time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	ReconciliationInterval    time.Duration `mapstructure:"RECONCILIATION_INTERVAL"`
+	ScheduledTransferInterval time.Duration `mapstructure:"SCHEDULED_TRANSFER_INTERVAL"`
+	HoldExpiryInterval        time.Duration `mapstructure:"HOLD_EXPIRY_INTERVAL"`
+	PostingSweepInterval      time.Duration `mapstructure:"POSTING_SWEEP_INTERVAL"`
+	RevokedTokenSweepInterval time.Duration `mapstructure:"REVOKED_TOKEN_SWEEP_INTERVAL"`
+	BootstrapAdmin            string        `mapstructure:"BOOTSTRAP_ADMIN"`
 }
 
 // LoadConfig to return all configuration
@@ -1,19 +1,10 @@
 package util
 
-func ValidCurrency(currency string) bool {
-	for _, cur := range AllowedCurrencies() {
-		if cur == currency {
-			return true
-		}
-	}
-	return false
-}
-
-func AllowedCurrencies() [4]string {
-	return [4]string{
-		"USD",
-		"SAR",
-		"LE",
-		"EUR",
-	}
-}
+// ISO 4217 codes the currencies table is seeded with, the table is the source of
+// truth for which currencies are supported
+const (
+	USD = "USD"
+	SAR = "SAR"
+	EGP = "EGP"
+	EUR = "EUR"
+)
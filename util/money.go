@@ -0,0 +1,137 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// errors returned by ParseMoney
+var (
+	ErrInvalidAmount = errors.New("amount must be a decimal number")
+	ErrTooPrecise    = errors.New("amount has more decimals than the currency")
+	ErrAmountRange   = errors.New("amount is out of range")
+)
+
+// Money is an amount of a currency counted in its minor units, exponent is the
+// number of minor unit digits, 2 for cents
+type Money struct {
+	MinorUnits int64
+	Currency   string
+	Exponent   int32
+}
+
+// NewMoney pair minorUnits with currency
+func NewMoney(minorUnits int64, currency string, exponent int32) Money {
+	return Money{MinorUnits: minorUnits, Currency: currency, Exponent: exponent}
+}
+
+// ParseMoney parse a decimal string such as "12.50" or "-3" in currency, it fails
+// with ErrTooPrecise when value has more decimals than exponent
+func ParseMoney(value string, currency string, exponent int32) (Money, error) {
+	money := Money{Currency: currency, Exponent: exponent}
+
+	digits := value
+	negative := strings.HasPrefix(digits, "-")
+	if negative {
+		digits = digits[1:]
+	}
+
+	whole, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, fraction = digits[:i], digits[i+1:]
+		if fraction == "" {
+			return money, ErrInvalidAmount
+		}
+	}
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) {
+		return money, ErrInvalidAmount
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > int(exponent) {
+		return money, ErrTooPrecise
+	}
+	fraction += strings.Repeat("0", int(exponent)-len(fraction))
+
+	minorUnits, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return money, ErrAmountRange
+	}
+	if negative {
+		minorUnits = -minorUnits
+	}
+
+	money.MinorUnits = minorUnits
+	return money, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal format the amount in units with exactly exponent decimals, "12.50"
+func (m Money) Decimal() string {
+	sign := ""
+	minorUnits := uint64(m.MinorUnits)
+	if m.MinorUnits < 0 {
+		sign = "-"
+		// negating in unsigned arithmetic keeps math.MinInt64 exact
+		minorUnits = -minorUnits
+	}
+
+	digits := strconv.FormatUint(minorUnits, 10)
+	if m.Exponent <= 0 {
+		return sign + digits
+	}
+
+	exponent := int(m.Exponent)
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// String format the amount for display, "12.50 USD"
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.Decimal(), m.Currency)
+}
+
+type moneyJSON struct {
+	Amount     string `json:"amount"`
+	Currency   string `json:"currency"`
+	MinorUnits int64  `json:"minor_units"`
+}
+
+// MarshalJSON encode m as {"amount": "12.50", "currency": "USD", "minor_units": 1250}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{
+		Amount:     m.Decimal(),
+		Currency:   m.Currency,
+		MinorUnits: m.MinorUnits,
+	})
+}
+
+// UnmarshalJSON decode the encoding of MarshalJSON, the exponent is taken from the
+// number of decimals of amount
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var exponent int32
+	if i := strings.IndexByte(v.Amount, '.'); i >= 0 {
+		exponent = int32(len(v.Amount) - i - 1)
+	}
+
+	*m = Money{MinorUnits: v.MinorUnits, Currency: v.Currency, Exponent: exponent}
+	return nil
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		value    string
+		exponent int32
+		want     int64
+		err      error
+	}{
+		{value: "12.50", exponent: 2, want: 1250},
+		{value: "12.5", exponent: 2, want: 1250},
+		{value: "12", exponent: 2, want: 1200},
+		{value: "0.01", exponent: 2, want: 1},
+		{value: "-3.10", exponent: 2, want: -310},
+		{value: "12.500", exponent: 2, want: 1250},
+		{value: "100", exponent: 0, want: 100},
+		{value: "1.234", exponent: 3, want: 1234},
+		{value: "12.505", exponent: 2, err: ErrTooPrecise},
+		{value: "1.5", exponent: 0, err: ErrTooPrecise},
+		{value: "", exponent: 2, err: ErrInvalidAmount},
+		{value: "12.", exponent: 2, err: ErrInvalidAmount},
+		{value: ".5", exponent: 2, err: ErrInvalidAmount},
+		{value: "1e3", exponent: 2, err: ErrInvalidAmount},
+		{value: "+1", exponent: 2, err: ErrInvalidAmount},
+		{value: "1,000", exponent: 2, err: ErrInvalidAmount},
+		{value: "92233720368547758.08", exponent: 2, err: ErrAmountRange},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			money, err := ParseMoney(tc.value, USD, tc.exponent)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, money.MinorUnits)
+			require.Equal(t, USD, money.Currency)
+		})
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	require.Equal(t, "12.50", NewMoney(1250, USD, 2).Decimal())
+	require.Equal(t, "0.05", NewMoney(5, USD, 2).Decimal())
+	require.Equal(t, "-0.05", NewMoney(-5, USD, 2).Decimal())
+	require.Equal(t, "1250", NewMoney(1250, USD, 0).Decimal())
+	require.Equal(t, "1.250", NewMoney(1250, USD, 3).Decimal())
+	require.Equal(t, "-92233720368547758.08", NewMoney(math.MinInt64, USD, 2).Decimal())
+	require.Equal(t, "12.50 EGP", NewMoney(1250, EGP, 2).String())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	money := NewMoney(1250, EUR, 2)
+
+	data, err := json.Marshal(money)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount": "12.50", "currency": "EUR", "minor_units": 1250}`, string(data))
+
+	var decoded Money
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, money, decoded)
+}
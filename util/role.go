@@ -0,0 +1,18 @@
+package util
+
+// roles a user can hold, stored on users.role and embedded in token payloads
+const (
+	RoleCustomer = "customer"
+	RoleTeller   = "teller"
+	RoleAdmin    = "admin"
+	RoleAuditor  = "auditor"
+)
+
+// ValidRole return true if role is a supported user role
+func ValidRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleTeller, RoleAdmin, RoleAuditor:
+		return true
+	}
+	return false
+}